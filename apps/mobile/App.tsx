@@ -9,6 +9,7 @@ import { DeckScreen } from './src/screens/DeckScreen';
 import { CreateScreen } from './src/screens/CreateScreen';
 import { useAuthStore } from './src/store/authStore';
 import { useSessionStore } from './src/store/sessionStore';
+import { api } from './src/services/api';
 
 export type RootStackParamList = {
   Onboarding: undefined;
@@ -41,8 +42,9 @@ export default function App() {
         await loadToken();
         await loadSession();
 
+        // Sessions from before device keys move over to a key
         const storedToken = useAuthStore.getState().token;
-        if (!storedToken) {
+        if (!storedToken || !(await api.hasDeviceKey())) {
           await authenticate();
         }
       } catch (error) {
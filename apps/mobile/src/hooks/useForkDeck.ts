@@ -54,11 +54,6 @@ export function useForkDeck() {
     nextFork();
   }, [interact, nextFork]);
 
-  const handleTwist = useCallback(async () => {
-    const dwellMs = Date.now() - dwellStartRef.current;
-    await interact('twist', dwellMs);
-  }, [interact]);
-
   const refresh = useCallback(() => {
     reset();
     loadFeed(lane || undefined, energy || undefined);
@@ -75,7 +70,6 @@ export function useForkDeck() {
     handleSwipeLeft,
     handleSwipeRight,
     handleSkip,
-    handleTwist,
     refresh,
   };
 }
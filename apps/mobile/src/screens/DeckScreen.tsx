@@ -41,7 +41,6 @@ export function DeckScreen() {
     handleSwipeLeft,
     handleSwipeRight,
     handleSkip,
-    handleTwist,
     refresh,
   } = useForkDeck();
 
@@ -49,11 +48,11 @@ export function DeckScreen() {
   const currentEnergy = energies.find((e) => e.id === energy);
 
   const handleTwistPress = useCallback(() => {
+    // Creating the twist records the interaction on the parent
     if (currentFork) {
-      handleTwist();
       navigation.navigate('Create', { parentForkId: currentFork.id });
     }
-  }, [currentFork, handleTwist, navigation]);
+  }, [currentFork, navigation]);
 
   const handleHomePress = useCallback(async () => {
     await resetSession();
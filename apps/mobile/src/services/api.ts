@@ -5,9 +5,14 @@ import {
   FeedResponse,
   IntentsResponse,
   AuthResponse,
+  ChallengeResponse,
   InteractionType,
   CreateForkInput,
 } from '../types';
+import { generateDeviceKey, loadDeviceKey, saveDeviceKey, signChallenge } from './deviceKey';
+
+// Error codes of a refresh that can never succeed; the session is over
+const SESSION_ENDED_CODES = ['refresh_token_reused', 'invalid_refresh_token', 'session_revoked'];
 
 // Demo mode - use mock data instead of real API
 const DEMO_MODE = true;
@@ -189,16 +194,42 @@ const MOCK_FORKS: Fork[] = [
   },
 ];
 
+// ApiError is an error response, carrying the envelope's machine-readable
+// code when the server sent one
+export class ApiError extends Error {
+  constructor(
+    message: string,
+    readonly status: number,
+    readonly code?: string
+  ) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
+type SessionListener = (session: AuthResponse | null) => void;
+
 class ApiClient {
   private token: string | null = null;
+  private refreshToken: string | null = null;
+  private refreshing: Promise<void> | null = null;
+  private sessionListener: SessionListener | null = null;
 
-  setToken(token: string | null) {
+  setSession(token: string | null, refreshToken: string | null) {
     this.token = token;
+    this.refreshToken = refreshToken;
+  }
+
+  // onSessionChange is told about every rotated token pair, and about the
+  // end of the session (null) when it can't be refreshed any more
+  onSessionChange(listener: SessionListener | null) {
+    this.sessionListener = listener;
   }
 
   private async request<T>(
     endpoint: string,
-    options: RequestInit = {}
+    options: RequestInit = {},
+    retry = true
   ): Promise<T> {
     const headers: HeadersInit = {
       'Content-Type': 'application/json',
@@ -214,29 +245,123 @@ class ApiClient {
       headers,
     });
 
+    // An expired access token gets one refresh and one retry
+    if (response.status === 401 && retry && this.refreshToken) {
+      await this.refreshSession();
+      return this.request<T>(endpoint, options, false);
+    }
+
     if (!response.ok) {
-      const error = await response.json().catch(() => ({}));
-      throw new Error(error.error || `HTTP ${response.status}`);
+      const body = await response.json().catch(() => ({}));
+      throw new ApiError(
+        body.error?.message || `HTTP ${response.status}`,
+        response.status,
+        body.error?.code
+      );
     }
 
     return response.json();
   }
 
+  // refreshSession exchanges the refresh token for a new pair. Each refresh
+  // token is single-use and presenting one twice revokes the session, so
+  // concurrent callers share one refresh.
+  private refreshSession(): Promise<void> {
+    if (!this.refreshing) {
+      this.refreshing = this.rotateTokens().finally(() => {
+        this.refreshing = null;
+      });
+    }
+    return this.refreshing;
+  }
+
+  private async rotateTokens(): Promise<void> {
+    const response = await fetch(`${API_BASE_URL}/auth/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: this.refreshToken }),
+    });
+
+    if (!response.ok) {
+      const body = await response.json().catch(() => ({}));
+      const code: string | undefined = body.error?.code;
+      if (code && SESSION_ENDED_CODES.includes(code)) {
+        this.setSession(null, null);
+        this.sessionListener?.(null);
+      }
+      throw new ApiError(
+        body.error?.message || `HTTP ${response.status}`,
+        response.status,
+        code
+      );
+    }
+
+    const session: AuthResponse = await response.json();
+    this.setSession(session.token, session.refresh_token);
+    this.sessionListener?.(session);
+  }
+
   // Auth
-  async authenticateDevice(): Promise<AuthResponse> {
+
+  // authenticateDevice signs in by answering a challenge with the device
+  // key, made on first use. Installs from before device keys pass the
+  // refresh token of their session, which hands their actor to the new key.
+  async authenticateDevice(legacyRefreshToken?: string | null): Promise<AuthResponse> {
     if (DEMO_MODE) {
       return {
         token: 'demo-token-' + Date.now(),
+        refresh_token: 'demo-refresh-' + Date.now(),
+        expires_in: 900,
         actor_id: 'demo-actor-' + Date.now(),
         is_new: true,
       };
     }
 
+    const storedKey = await loadDeviceKey();
+    const key = storedKey ?? generateDeviceKey();
     const fingerprint = await this.getDeviceFingerprint();
-    return this.request<AuthResponse>('/auth/device', {
-      method: 'POST',
-      body: JSON.stringify({ device_fingerprint: fingerprint }),
-    });
+
+    // Auth endpoints never refresh; a 401 here is the answer
+    const { challenge } = await this.request<ChallengeResponse>(
+      '/auth/challenge',
+      { method: 'POST' },
+      false
+    );
+    const result = await this.request<AuthResponse>(
+      '/auth/device',
+      {
+        method: 'POST',
+        body: JSON.stringify({
+          device_fingerprint: fingerprint,
+          public_key: key.publicKey,
+          challenge,
+          signature: signChallenge(key, challenge),
+          legacy_refresh_token: storedKey ? undefined : legacyRefreshToken || undefined,
+        }),
+      },
+      false
+    );
+
+    if (!storedKey) {
+      await saveDeviceKey(key);
+    }
+    return result;
+  }
+
+  async hasDeviceKey(): Promise<boolean> {
+    return DEMO_MODE || (await loadDeviceKey()) !== null;
+  }
+
+  // logout revokes the session on the server; its tokens are dropped either
+  // way
+  async logout(): Promise<void> {
+    try {
+      if (!DEMO_MODE && this.token) {
+        await this.request('/auth/logout', { method: 'POST' });
+      }
+    } finally {
+      this.setSession(null, null);
+    }
   }
 
   private async getDeviceFingerprint(): Promise<string> {
@@ -338,7 +463,6 @@ class ApiClient {
         if (type === 'swipe_left') fork.left_count++;
         else if (type === 'swipe_right') fork.right_count++;
         else if (type === 'skip') fork.skip_count++;
-        else if (type === 'twist') fork.twist_count++;
       }
       return;
     }
@@ -388,14 +512,14 @@ class ApiClient {
   }
 
   // Session
-  async updateSession(lane: string, energy: string): Promise<void> {
+  async updateSession(lane: string, energy: string, mood?: string): Promise<void> {
     if (DEMO_MODE) {
       return;
     }
 
     await this.request('/session', {
       method: 'PUT',
-      body: JSON.stringify({ lane, energy }),
+      body: JSON.stringify({ lane, energy, mood }),
     });
   }
 }
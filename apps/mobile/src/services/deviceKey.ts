@@ -0,0 +1,62 @@
+import * as Crypto from 'expo-crypto';
+import { ed25519 } from '@noble/curves/ed25519';
+import { storage } from './storage';
+
+const DEVICE_KEY_KEY = 'forkfall_device_key';
+
+// SubjectPublicKeyInfo header of an Ed25519 key; the server takes device
+// keys as PKIX DER
+const ED25519_SPKI_PREFIX = [0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00];
+
+// DeviceKey is the keypair a device signs in with. The private key never
+// leaves the device.
+export interface DeviceKey {
+  seed: Uint8Array;
+  // publicKey is the PKIX DER public key, base64 encoded
+  publicKey: string;
+}
+
+export function toBase64(bytes: Uint8Array): string {
+  let binary = '';
+  for (const byte of bytes) {
+    binary += String.fromCharCode(byte);
+  }
+  return btoa(binary);
+}
+
+function fromBase64(value: string): Uint8Array {
+  const binary = atob(value);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes;
+}
+
+function fromSeed(seed: Uint8Array): DeviceKey {
+  const der = new Uint8Array([...ED25519_SPKI_PREFIX, ...ed25519.getPublicKey(seed)]);
+  return { seed, publicKey: toBase64(der) };
+}
+
+// loadDeviceKey returns the key this device signed in with before, if any
+export async function loadDeviceKey(): Promise<DeviceKey | null> {
+  const stored = await storage.getItem(DEVICE_KEY_KEY);
+  return stored ? fromSeed(fromBase64(stored)) : null;
+}
+
+// generateDeviceKey makes a new key. It is only kept by saveDeviceKey, once
+// the server has registered it.
+export function generateDeviceKey(): DeviceKey {
+  return fromSeed(Crypto.getRandomBytes(32));
+}
+
+export async function saveDeviceKey(key: DeviceKey): Promise<void> {
+  await storage.setItem(DEVICE_KEY_KEY, toBase64(key.seed));
+}
+
+// signChallenge signs a challenge nonce, returning the base64 signature.
+// Nonces are base64url, so their characters are their bytes.
+export function signChallenge(key: DeviceKey, challenge: string): string {
+  const message = Uint8Array.from(challenge, (c) => c.charCodeAt(0));
+  return toBase64(ed25519.sign(message, key.seed));
+}
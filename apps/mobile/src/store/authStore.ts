@@ -1,34 +1,23 @@
 import { create } from 'zustand';
-import { Platform } from 'react-native';
-import * as SecureStore from 'expo-secure-store';
 import { api } from '../services/api';
+import { storage } from '../services/storage';
+import { AuthResponse } from '../types';
 
 const TOKEN_KEY = 'forkfall_token';
+const REFRESH_TOKEN_KEY = 'forkfall_refresh_token';
 const ACTOR_ID_KEY = 'forkfall_actor_id';
 
-// Storage abstraction for web/native
-const storage = {
-  async getItem(key: string): Promise<string | null> {
-    if (Platform.OS === 'web') {
-      return localStorage.getItem(key);
-    }
-    return SecureStore.getItemAsync(key);
-  },
-  async setItem(key: string, value: string): Promise<void> {
-    if (Platform.OS === 'web') {
-      localStorage.setItem(key, value);
-      return;
-    }
-    return SecureStore.setItemAsync(key, value);
-  },
-  async deleteItem(key: string): Promise<void> {
-    if (Platform.OS === 'web') {
-      localStorage.removeItem(key);
-      return;
-    }
-    return SecureStore.deleteItemAsync(key);
-  },
-};
+async function saveSession(session: AuthResponse) {
+  await storage.setItem(TOKEN_KEY, session.token);
+  await storage.setItem(REFRESH_TOKEN_KEY, session.refresh_token);
+  await storage.setItem(ACTOR_ID_KEY, session.actor_id);
+}
+
+async function clearSession() {
+  await storage.deleteItem(TOKEN_KEY);
+  await storage.deleteItem(REFRESH_TOKEN_KEY);
+  await storage.deleteItem(ACTOR_ID_KEY);
+}
 
 interface AuthState {
   token: string | null;
@@ -52,10 +41,11 @@ export const useAuthStore = create<AuthState>((set, get) => ({
   loadToken: async () => {
     try {
       const token = await storage.getItem(TOKEN_KEY);
+      const refreshToken = await storage.getItem(REFRESH_TOKEN_KEY);
       const actorId = await storage.getItem(ACTOR_ID_KEY);
 
       if (token) {
-        api.setToken(token);
+        api.setSession(token, refreshToken);
         set({
           token,
           actorId,
@@ -71,12 +61,13 @@ export const useAuthStore = create<AuthState>((set, get) => ({
     set({ isLoading: true, error: null });
 
     try {
-      const result = await api.authenticateDevice();
-
-      await storage.setItem(TOKEN_KEY, result.token);
-      await storage.setItem(ACTOR_ID_KEY, result.actor_id);
+      // Before device keys the app kept only a session; its refresh token
+      // claims the actor for this device's new key
+      const legacyRefreshToken = await storage.getItem(REFRESH_TOKEN_KEY);
+      const result = await api.authenticateDevice(legacyRefreshToken);
 
-      api.setToken(result.token);
+      await saveSession(result);
+      api.setSession(result.token, result.refresh_token);
 
       set({
         token: result.token,
@@ -92,10 +83,13 @@ export const useAuthStore = create<AuthState>((set, get) => ({
 
   logout: async () => {
     try {
-      await storage.deleteItem(TOKEN_KEY);
-      await storage.deleteItem(ACTOR_ID_KEY);
+      await api.logout();
+    } catch (error) {
+      console.error('Failed to revoke session:', error);
+    }
 
-      api.setToken(null);
+    try {
+      await clearSession();
 
       set({
         token: null,
@@ -107,3 +101,20 @@ export const useAuthStore = create<AuthState>((set, get) => ({
     }
   },
 }));
+
+// Keep the stored tokens in step with every refresh, and sign out when the
+// session can't be refreshed any more (e.g. a refresh token was reused and
+// the server revoked the session)
+api.onSessionChange(async (session) => {
+  try {
+    if (session) {
+      await saveSession(session);
+      useAuthStore.setState({ token: session.token, actorId: session.actor_id, isAuthenticated: true });
+      return;
+    }
+    await clearSession();
+    useAuthStore.setState({ token: null, actorId: null, isAuthenticated: false });
+  } catch (error) {
+    console.error('Failed to store session:', error);
+  }
+});
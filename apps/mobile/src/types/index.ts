@@ -21,6 +21,7 @@ export interface Fork {
 
 export interface FeedResponse {
   forks: Fork[];
+  session?: Session;
   next_cursor?: string;
 }
 
@@ -37,18 +38,27 @@ export interface IntentsResponse {
   moods: IntentOption[];
 }
 
+export interface ChallengeResponse {
+  challenge: string;
+  expires_at: string;
+}
+
 export interface AuthResponse {
   token: string;
+  refresh_token: string;
+  expires_in: number;
   actor_id: string;
   is_new: boolean;
+  recovery_code?: string;
 }
 
 export interface Session {
   lane: string;
   energy: string;
+  mood: string;
 }
 
-export type InteractionType = 'swipe_left' | 'swipe_right' | 'skip' | 'twist';
+export type InteractionType = 'swipe_left' | 'swipe_right' | 'skip';
 
 export interface CreateForkInput {
   prompt: string;
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/forkfall/backend/internal/admintoken"
+	"github.com/forkfall/backend/internal/domain"
+)
+
+const adminTokenUsage = `usage: api admin-token -staff <id> -scopes <scope,...> [-ttl 8h]
+
+Prints a signed admin API token. Requires ADMIN_TOKEN_SECRET.
+
+scopes: actors:read actors:write forks:read forks:write
+        reports:read reports:write audit:read`
+
+// runAdminToken implements the `api admin-token` subcommand
+func runAdminToken(secret string, args []string) {
+	fs := flag.NewFlagSet("admin-token", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, adminTokenUsage) }
+	staffID := fs.String("staff", "", "staff ID recorded in the audit log, e.g. an email")
+	scopes := fs.String("scopes", "", "comma-separated scopes to grant")
+	ttl := fs.Duration("ttl", 8*time.Hour, "token lifetime (max 24h)")
+	fs.Parse(args)
+
+	if secret == "" {
+		log.Fatal("ADMIN_TOKEN_SECRET is not set")
+	}
+	if *staffID == "" || *scopes == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	staff := &domain.Staff{ID: *staffID, Scopes: strings.Split(*scopes, ",")}
+	token, err := admintoken.Issue([]byte(secret), staff, *ttl, time.Now())
+	if err != nil {
+		log.Fatalf("Failed to issue admin token: %v", err)
+	}
+	fmt.Println(token)
+}
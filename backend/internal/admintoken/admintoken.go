@@ -0,0 +1,74 @@
+// Package admintoken issues and verifies the signed bearer tokens used by
+// the admin API. They are HS256 JWTs carrying the staff member's ID and
+// granted scopes, signed with a secret separate from actor tokens and bound
+// to their own audience so neither kind is accepted in place of the other.
+package admintoken
+
+import (
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const audience = "forkfall-admin"
+
+// MaxTTL bounds how long an admin token may stay valid
+const MaxTTL = 24 * time.Hour
+
+var (
+	ErrInvalidToken = errors.New("invalid admin token")
+	errTTL          = errors.New("admin token lifetime must be between 1s and 24h")
+	errScope        = errors.New("unknown admin scope")
+	errStaffID      = errors.New("admin token requires a staff ID")
+)
+
+type claims struct {
+	Scopes []string `json:"scopes"`
+	jwt.RegisteredClaims
+}
+
+// Issue signs a token for staff that expires after ttl
+func Issue(secret []byte, staff *domain.Staff, ttl time.Duration, now time.Time) (string, error) {
+	if staff.ID == "" {
+		return "", errStaffID
+	}
+	if ttl < time.Second || ttl > MaxTTL {
+		return "", errTTL
+	}
+	for _, scope := range staff.Scopes {
+		if !domain.ValidAdminScope(scope) {
+			return "", errScope
+		}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Scopes: staff.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   staff.ID,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	return token.SignedString(secret)
+}
+
+// Verify checks the token's signature, audience and expiry and returns the
+// staff member it was issued to
+func Verify(secret []byte, tokenString string) (*domain.Staff, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(tokenString, &c, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return &domain.Staff{ID: c.Subject, Scopes: c.Scopes}, nil
+}
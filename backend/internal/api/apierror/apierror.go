@@ -0,0 +1,171 @@
+// Package apierror turns errors into the JSON error envelope returned by
+// every API endpoint:
+//
+//	{"error": {"code": "prompt_too_long", "message": "...", "request_id": "...",
+//	           "fields": [{"field": "prompt", "message": "..."}]}}
+//
+// Domain sentinels (and errors wrapping them) are mapped to a status and a
+// machine-readable code; anything unrecognised becomes a logged 500.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/forkfall/backend/internal/domain"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Error is an API error with an explicit status and code
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+// FieldError points an error at a specific request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an API error
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Common request-level errors raised by handlers and middleware
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(http.StatusUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+// InvalidBody is returned when a request body can't be decoded
+var InvalidBody = BadRequest("invalid_body", "invalid request body")
+
+// mapping describes how a domain sentinel is presented to clients
+type mapping struct {
+	sentinel error
+	status   int
+	code     string
+}
+
+// mappings is checked in order with errors.Is
+var mappings = []mapping{
+	{domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{domain.ErrPromptTooLong, http.StatusBadRequest, "prompt_too_long"},
+	{domain.ErrLabelTooLong, http.StatusBadRequest, "label_too_long"},
+	{domain.ErrMissingRequired, http.StatusBadRequest, "missing_required"},
+	{domain.ErrInvalidInput, http.StatusBadRequest, "invalid_input"},
+	{domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+	{domain.ErrActorSuspended, http.StatusForbidden, "actor_suspended"},
+	{domain.ErrActorBanned, http.StatusForbidden, "actor_banned"},
+	{domain.ErrLowTrust, http.StatusForbidden, "trust_too_low"},
+	{domain.ErrMutationRequiresParent, http.StatusBadRequest, "mutation_requires_parent"},
+	{domain.ErrInvalidMutation, http.StatusBadRequest, "invalid_mutation"},
+	{domain.ErrUnknownMutation, http.StatusBadRequest, "unknown_mutation"},
+	{domain.ErrParentUnavailable, http.StatusBadRequest, "parent_unavailable"},
+	{domain.ErrInvalidTransition, http.StatusConflict, "invalid_transition"},
+	{domain.ErrDuplicateReport, http.StatusConflict, "duplicate_report"},
+	{domain.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+	{domain.ErrRefreshTokenReused, http.StatusUnauthorized, "refresh_token_reused"},
+	{domain.ErrSessionRevoked, http.StatusUnauthorized, "session_revoked"},
+	{domain.ErrInvalidChallenge, http.StatusUnauthorized, "invalid_challenge"},
+	{domain.ErrInvalidDeviceSignature, http.StatusUnauthorized, "invalid_device_signature"},
+	{domain.ErrAttestationRequired, http.StatusUnauthorized, "attestation_required"},
+	{domain.ErrInvalidAttestation, http.StatusUnauthorized, "invalid_attestation"},
+	{domain.ErrInvalidLinkCode, http.StatusUnauthorized, "invalid_link_code"},
+	{domain.ErrInvalidRecoveryCode, http.StatusUnauthorized, "invalid_recovery_code"},
+	{domain.ErrDeviceAlreadyRegistered, http.StatusConflict, "device_already_registered"},
+	{domain.ErrLastDevice, http.StatusConflict, "last_device"},
+	{domain.ErrAlreadyClaimed, http.StatusConflict, "already_claimed"},
+}
+
+// From converts any error into an API error
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, m := range mappings {
+		if errors.Is(err, m.sentinel) {
+			e := New(m.status, m.code, m.sentinel.Error())
+			var fieldErr *domain.FieldError
+			if errors.As(err, &fieldErr) {
+				e.Fields = []FieldError{{Field: fieldErr.Field, Message: fieldErr.Err.Error()}}
+			}
+			return e
+		}
+	}
+
+	return New(http.StatusInternalServerError, "internal_error", "internal server error")
+}
+
+type envelope struct {
+	Error body `json:"error"`
+}
+
+type body struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"request_id,omitempty"`
+	Fields    []FieldError `json:"fields,omitempty"`
+}
+
+// Write maps err and writes it as the JSON error envelope. Unmapped errors
+// are logged with the request ID since their detail is not sent to clients.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	requestID := chimiddleware.GetReqID(r.Context())
+
+	if e.Status == http.StatusInternalServerError {
+		log.Printf("[%s] %s %s: %v", requestID, r.Method, r.URL.Path, err)
+	}
+
+	var rateErr *domain.RateLimitError
+	if errors.As(err, &rateErr) {
+		w.Header().Set("Retry-After", RetryAfterSeconds(rateErr.RetryAfter.Seconds()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(envelope{Error: body{
+		Code:      e.Code,
+		Message:   e.Message,
+		RequestID: requestID,
+		Fields:    e.Fields,
+	}})
+}
+
+// RetryAfterSeconds formats a Retry-After value, rounding up to at least one
+// whole second
+func RetryAfterSeconds(seconds float64) string {
+	secs := int64(math.Ceil(seconds))
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.FormatInt(secs, 10)
+}
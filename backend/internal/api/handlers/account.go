@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var errInvalidJobID = apierror.BadRequest("invalid_job_id", "invalid job id")
+
+// AccountHandler serves account export and deletion
+type AccountHandler struct {
+	accountService *service.AccountService
+}
+
+func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// AccountJobResponse describes an export or deletion job. Archive is only
+// included for a completed export fetched by its owner.
+type AccountJobResponse struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Status      string          `json:"status"`
+	CreatedAt   string          `json:"created_at"`
+	CompletedAt string          `json:"completed_at,omitempty"`
+	Archive     json.RawMessage `json:"archive,omitempty"`
+}
+
+func newAccountJobResponse(job *domain.AccountJob) AccountJobResponse {
+	resp := AccountJobResponse{
+		ID:        job.ID.String(),
+		Kind:      job.Kind,
+		Status:    job.Status,
+		CreatedAt: job.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if job.CompletedAt != nil {
+		resp.CompletedAt = job.CompletedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return resp
+}
+
+// DeleteAccount queues the deletion of the caller's account. Its progress
+// is polled with GetJob, which keeps working after the account is gone.
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	job, err := h.accountService.RequestDeletion(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newAccountJobResponse(job))
+}
+
+// RequestExport queues an export of the caller's data, to be fetched with
+// GetExport once it completes
+func (h *AccountHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	job, err := h.accountService.RequestExport(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newAccountJobResponse(job))
+}
+
+// GetExport returns the caller's latest export job, including the archive
+// once it has completed
+func (h *AccountHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	job, err := h.accountService.GetExport(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := newAccountJobResponse(job)
+	if job.Status == domain.AccountJobCompleted {
+		resp.Archive = job.Archive
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetJob returns the status of any account job. It needs no credentials,
+// as the random job ID is only known to whoever requested the job, and
+// never includes an export's archive.
+func (h *AccountHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidJobID)
+		return
+	}
+
+	job, err := h.accountService.GetJob(r.Context(), jobID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newAccountJobResponse(job))
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/api/projection"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+var (
+	errInvalidActorID  = apierror.BadRequest("invalid_actor_id", "invalid actor id")
+	errInvalidReportID = apierror.BadRequest("invalid_report_id", "invalid report id")
+)
+
+// adminLimit parses the limit query parameter for admin listings
+func adminLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return service.AdminListDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > service.AdminListMaxLimit {
+		return 0, &domain.FieldError{Field: "limit", Err: domain.ErrInvalidInput}
+	}
+	return limit, nil
+}
+
+// ============ Actors ============
+
+type AdminActorResponse struct {
+	ID                string         `json:"id"`
+	DeviceFingerprint string         `json:"device_fingerprint"`
+	TrustScore        float64        `json:"trust_score"`
+	Trust             *TrustResponse `json:"trust,omitempty"`
+	Status            string         `json:"status"`
+	CreatedAt         string         `json:"created_at"`
+	ReportsDismissed  int            `json:"reports_dismissed"`
+	ReportsActioned   int            `json:"reports_actioned"`
+}
+
+// TrustResponse explains an actor's trust score
+type TrustResponse struct {
+	Computed      float64                    `json:"computed"`
+	Override      *float64                   `json:"override,omitempty"`
+	Contributions []domain.TrustContribution `json:"contributions"`
+	ComputedAt    string                     `json:"computed_at,omitempty"`
+}
+
+func newAdminActorResponse(review *service.ActorReview) AdminActorResponse {
+	resp := AdminActorResponse{
+		ID:                review.Actor.ID.String(),
+		DeviceFingerprint: review.Actor.DeviceFingerprint,
+		TrustScore:        review.Actor.TrustScore,
+		Status:            review.Actor.Status,
+		CreatedAt:         review.Actor.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		ReportsDismissed:  review.Reports.Dismissed,
+		ReportsActioned:   review.Reports.Actioned,
+	}
+	if trust := review.Trust; trust != nil {
+		resp.Trust = &TrustResponse{
+			Computed:      trust.Computed,
+			Override:      trust.Override,
+			Contributions: trust.Contributions,
+		}
+		if resp.Trust.Contributions == nil {
+			resp.Trust.Contributions = []domain.TrustContribution{}
+		}
+		if !trust.ComputedAt.IsZero() {
+			resp.Trust.ComputedAt = trust.ComputedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+	}
+	return resp
+}
+
+// FindActor looks an actor up by device fingerprint
+func (h *AdminHandler) FindActor(w http.ResponseWriter, r *http.Request) {
+	fingerprint := r.URL.Query().Get("fingerprint")
+	if fingerprint == "" {
+		apierror.Write(w, r, &domain.FieldError{Field: "fingerprint", Err: domain.ErrMissingRequired})
+		return
+	}
+
+	review, err := h.adminService.FindActor(r.Context(), fingerprint)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newAdminActorResponse(review))
+}
+
+func (h *AdminHandler) GetActor(w http.ResponseWriter, r *http.Request) {
+	actorID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidActorID)
+		return
+	}
+
+	review, err := h.adminService.GetActor(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newAdminActorResponse(review))
+}
+
+type SetActorStatusRequest struct {
+	Status string `json:"status"` // active, suspended, banned
+	Reason string `json:"reason"`
+}
+
+func (h *AdminHandler) SetActorStatus(w http.ResponseWriter, r *http.Request) {
+	staff, _ := middleware.GetStaff(r.Context())
+
+	actorID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidActorID)
+		return
+	}
+
+	var req SetActorStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
+	}
+
+	if _, err := h.adminService.SetActorStatus(r.Context(), staff, actorID, req.Status, req.Reason); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	h.GetActor(w, r)
+}
+
+type SetTrustScoreRequest struct {
+	TrustScore *float64 `json:"trust_score"` // null returns the actor to their computed score
+	Reason     string   `json:"reason"`
+}
+
+func (h *AdminHandler) SetTrustScore(w http.ResponseWriter, r *http.Request) {
+	staff, _ := middleware.GetStaff(r.Context())
+
+	actorID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidActorID)
+		return
+	}
+
+	var req SetTrustScoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
+	}
+
+	if _, err := h.adminService.SetTrustScore(r.Context(), staff, actorID, req.TrustScore, req.Reason); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	h.GetActor(w, r)
+}
+
+// ============ Reports ============
+
+type ReportResponse struct {
+	ID        string `json:"id"`
+	ActorID   string `json:"actor_id"`
+	ForkID    string `json:"fork_id"`
+	Reason    string `json:"reason"`
+	State     string `json:"state"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newReportResponse(report *domain.Report) ReportResponse {
+	return ReportResponse{
+		ID:        report.ID.String(),
+		ActorID:   report.ActorID.String(),
+		ForkID:    report.ForkID.String(),
+		Reason:    report.Reason,
+		State:     report.State,
+		CreatedAt: report.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+type ReportQueueResponse struct {
+	Reports    []ReportResponse `json:"reports"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func (h *AdminHandler) ListReports(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = domain.ReportStatePending
+	}
+
+	limit, err := adminLimit(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	reports, nextCursor, err := h.adminService.ListReports(r.Context(), state, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := ReportQueueResponse{
+		Reports:    make([]ReportResponse, len(reports)),
+		NextCursor: nextCursor,
+	}
+	for i, report := range reports {
+		resp.Reports[i] = newReportResponse(report)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+type ResolveReportRequest struct {
+	State string `json:"state"` // reviewed, dismissed, actioned
+	Note  string `json:"note,omitempty"`
+}
+
+func (h *AdminHandler) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	staff, _ := middleware.GetStaff(r.Context())
+
+	reportID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidReportID)
+		return
+	}
+
+	var req ResolveReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
+	}
+
+	report, err := h.adminService.ResolveReport(r.Context(), staff, reportID, req.State, req.Note)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newReportResponse(report))
+}
+
+// ============ Forks ============
+
+type ModerationActionResponse struct {
+	ID        string `json:"id"`
+	Moderator string `json:"moderator,omitempty"`
+	Action    string `json:"action"`
+	ReportID  string `json:"report_id,omitempty"`
+	Note      string `json:"note,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ForkReviewResponse struct {
+	Fork          projection.Fork            `json:"fork"`
+	Status        string                     `json:"status"`
+	CreatedBy     string                     `json:"created_by_actor_id"`
+	CreatedByMask string                     `json:"created_by_mask_id,omitempty"`
+	ReportWeight  float64                    `json:"report_weight"`
+	Actions       []ModerationActionResponse `json:"actions"`
+}
+
+func (h *AdminHandler) GetFork(w http.ResponseWriter, r *http.Request) {
+	forkID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidForkID)
+		return
+	}
+
+	review, err := h.adminService.GetForkReview(r.Context(), forkID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := ForkReviewResponse{
+		Fork:         projection.NewFork(review.Fork, uuid.Nil),
+		Status:       review.Fork.Status,
+		CreatedBy:    review.Fork.CreatedByActorID.String(),
+		ReportWeight: review.ReportWeight,
+		Actions:      make([]ModerationActionResponse, len(review.Actions)),
+	}
+	if review.Fork.CreatedByMaskID != nil {
+		resp.CreatedByMask = review.Fork.CreatedByMaskID.String()
+	}
+	for i, action := range review.Actions {
+		a := ModerationActionResponse{
+			ID:        action.ID.String(),
+			Moderator: action.Moderator,
+			Action:    action.Action,
+			Note:      action.Note,
+			CreatedAt: action.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		}
+		if action.ReportID != nil {
+			a.ReportID = action.ReportID.String()
+		}
+		resp.Actions[i] = a
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+type ModerateForkRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *AdminHandler) RestoreFork(w http.ResponseWriter, r *http.Request) {
+	h.moderateFork(w, r, h.adminService.RestoreFork)
+}
+
+func (h *AdminHandler) RemoveFork(w http.ResponseWriter, r *http.Request) {
+	h.moderateFork(w, r, h.adminService.RemoveFork)
+}
+
+// moderateFork handles the fork decision endpoints, which share a request
+// shape and differ only in the service call
+func (h *AdminHandler) moderateFork(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, staff *domain.Staff, forkID uuid.UUID, reason string) error) {
+	staff, _ := middleware.GetStaff(r.Context())
+
+	forkID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidForkID)
+		return
+	}
+
+	var req ModerateForkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
+	}
+
+	if err := decide(r.Context(), staff, forkID, req.Reason); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	h.GetFork(w, r)
+}
+
+// ============ Audit log ============
+
+type AuditEntryResponse struct {
+	ID         string         `json:"id"`
+	StaffID    string         `json:"staff_id"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type"`
+	TargetID   string         `json:"target_id"`
+	Details    map[string]any `json:"details"`
+	CreatedAt  string         `json:"created_at"`
+}
+
+type AuditLogResponse struct {
+	Entries    []AuditEntryResponse `json:"entries"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	var targetID *uuid.UUID
+	if targetStr := r.URL.Query().Get("target_id"); targetStr != "" {
+		id, err := uuid.Parse(targetStr)
+		if err != nil {
+			apierror.Write(w, r, &domain.FieldError{Field: "target_id", Err: domain.ErrInvalidInput})
+			return
+		}
+		targetID = &id
+	}
+
+	limit, err := adminLimit(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	entries, nextCursor, err := h.adminService.ListAudit(r.Context(), targetID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := AuditLogResponse{
+		Entries:    make([]AuditEntryResponse, len(entries)),
+		NextCursor: nextCursor,
+	}
+	for i, entry := range entries {
+		resp.Entries[i] = AuditEntryResponse{
+			ID:         entry.ID.String(),
+			StaffID:    entry.StaffID,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID.String(),
+			Details:    entry.Details,
+			CreatedAt:  entry.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -3,7 +3,11 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/domain"
 	"github.com/forkfall/backend/internal/service"
 )
 
@@ -17,40 +21,157 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 	}
 }
 
+type ChallengeResponse struct {
+	Challenge string `json:"challenge"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// DeviceAuthRequest answers a challenge. PublicKey is the device's
+// DER-encoded PKIX Ed25519 or P-256 key and Signature its signature over
+// the challenge string, both base64-encoded. A new key is linked to an
+// existing actor by giving one of LinkCode or RecoveryCode, or take over an
+// actor that signed up before device keys existed by giving LegacyRefreshToken,
+// a refresh token from one of their sessions.
 type DeviceAuthRequest struct {
-	DeviceFingerprint string `json:"device_fingerprint"`
+	DeviceFingerprint  string              `json:"device_fingerprint"`
+	PublicKey          []byte              `json:"public_key"`
+	Challenge          string              `json:"challenge"`
+	Signature          []byte              `json:"signature"`
+	Attestation        *AttestationRequest `json:"attestation,omitempty"`
+	LinkCode           string              `json:"link_code,omitempty"`
+	RecoveryCode       string              `json:"recovery_code,omitempty"`
+	LegacyRefreshToken string              `json:"legacy_refresh_token,omitempty"`
+}
+
+// AttestationRequest is a platform attestation of the device key
+type AttestationRequest struct {
+	Format    string `json:"format"`
+	Statement []byte `json:"statement"`
 }
 
+// DeviceAuthResponse carries a token pair. ExpiresIn is the access token
+// lifetime in seconds. RecoveryCode is only present when the actor was
+// issued a new one, and is never shown again.
 type DeviceAuthResponse struct {
-	Token   string `json:"token"`
-	ActorID string `json:"actor_id"`
-	IsNew   bool   `json:"is_new"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	ActorID      string `json:"actor_id"`
+	IsNew        bool   `json:"is_new"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+func newDeviceAuthResponse(result *service.AuthResult) DeviceAuthResponse {
+	return DeviceAuthResponse{
+		Token:        result.Token,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    int(result.ExpiresIn / time.Second),
+		ActorID:      result.ActorID.String(),
+		IsNew:        result.IsNew,
+		RecoveryCode: result.RecoveryCode,
+	}
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Challenge issues a nonce for the device to sign in DeviceAuth
+func (h *AuthHandler) Challenge(w http.ResponseWriter, r *http.Request) {
+	challenge, err := h.authService.NewChallenge(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ChallengeResponse{
+		Challenge: challenge.Nonce,
+		ExpiresAt: challenge.ExpiresAt.UTC().Format("2006-01-02T15:04:05Z"),
+	})
 }
 
 func (h *AuthHandler) DeviceAuth(w http.ResponseWriter, r *http.Request) {
 	var req DeviceAuthRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody)
 		return
 	}
 
-	if req.DeviceFingerprint == "" {
-		http.Error(w, `{"error":"device_fingerprint is required"}`, http.StatusBadRequest)
+	var missing string
+	switch {
+	case req.DeviceFingerprint == "":
+		missing = "device_fingerprint"
+	case len(req.PublicKey) == 0:
+		missing = "public_key"
+	case req.Challenge == "":
+		missing = "challenge"
+	case len(req.Signature) == 0:
+		missing = "signature"
+	}
+	if missing != "" {
+		apierror.Write(w, r, &domain.FieldError{Field: missing, Err: domain.ErrMissingRequired})
 		return
 	}
 
-	result, err := h.authService.AuthenticateDevice(r.Context(), req.DeviceFingerprint)
+	input := service.DeviceAuthInput{
+		Fingerprint:        req.DeviceFingerprint,
+		PublicKey:          req.PublicKey,
+		Challenge:          req.Challenge,
+		Signature:          req.Signature,
+		LinkCode:           req.LinkCode,
+		RecoveryCode:       req.RecoveryCode,
+		LegacyRefreshToken: req.LegacyRefreshToken,
+	}
+	if req.Attestation != nil {
+		input.AttestationFormat = req.Attestation.Format
+		input.Attestation = req.Attestation.Statement
+	}
+
+	result, err := h.authService.AuthenticateDevice(r.Context(), input)
 	if err != nil {
-		http.Error(w, `{"error":"authentication failed"}`, http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
-	resp := DeviceAuthResponse{
-		Token:   result.Token,
-		ActorID: result.ActorID.String(),
-		IsNew:   result.IsNew,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newDeviceAuthResponse(result))
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		apierror.Write(w, r, &domain.FieldError{Field: "refresh_token", Err: domain.ErrMissingRequired})
+		return
+	}
+
+	result, err := h.authService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newDeviceAuthResponse(result))
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := middleware.GetSessionID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), sessionID); err != nil {
+		apierror.Write(w, r, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	w.Write([]byte(`{"status":"ok"}`))
 }
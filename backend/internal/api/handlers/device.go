@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+var errInvalidDeviceID = apierror.BadRequest("invalid_device_id", "invalid device id")
+
+// DeviceHandler manages the devices linked to the signed-in actor and their
+// recovery code
+type DeviceHandler struct {
+	authService *service.AuthService
+}
+
+func NewDeviceHandler(authService *service.AuthService) *DeviceHandler {
+	return &DeviceHandler{
+		authService: authService,
+	}
+}
+
+// DeviceResponse describes a linked device. Current marks the device making
+// the request.
+type DeviceResponse struct {
+	ID                string `json:"id"`
+	Fingerprint       string `json:"fingerprint"`
+	AttestationFormat string `json:"attestation_format,omitempty"`
+	CreatedAt         string `json:"created_at"`
+	LastSeenAt        string `json:"last_seen_at"`
+	Current           bool   `json:"current"`
+}
+
+type DeviceListResponse struct {
+	Devices []DeviceResponse `json:"devices"`
+}
+
+type LinkCodeResponse struct {
+	LinkCode  string `json:"link_code"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type RecoveryCodeResponse struct {
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (h *DeviceHandler) ListDevices(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+	sessionID, ok := middleware.GetSessionID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	devices, err := h.authService.ListDevices(r.Context(), actorID, sessionID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	response := DeviceListResponse{Devices: make([]DeviceResponse, len(devices))}
+	for i, device := range devices {
+		response.Devices[i] = DeviceResponse{
+			ID:                device.ID.String(),
+			Fingerprint:       device.Fingerprint,
+			AttestationFormat: device.AttestationFormat,
+			CreatedAt:         device.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			LastSeenAt:        device.LastSeenAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Current:           device.Current,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateLinkCode issues a code for signing a new device in to this actor
+func (h *DeviceHandler) CreateLinkCode(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	code, err := h.authService.NewLinkCode(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LinkCodeResponse{
+		LinkCode:  code.Code,
+		ExpiresAt: code.ExpiresAt.UTC().Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+// UnlinkDevice removes a device and signs it out. The device may be the one
+// making the request.
+func (h *DeviceHandler) UnlinkDevice(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	deviceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		apierror.Write(w, r, errInvalidDeviceID)
+		return
+	}
+
+	if err := h.authService.UnlinkDevice(r.Context(), actorID, deviceID); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// RegenerateRecoveryCode replaces the actor's recovery code. The old code
+// stops working immediately.
+func (h *DeviceHandler) RegenerateRecoveryCode(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	code, err := h.authService.RegenerateRecoveryCode(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RecoveryCodeResponse{RecoveryCode: code})
+}
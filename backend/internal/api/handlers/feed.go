@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/forkfall/backend/internal/api/apierror"
 	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/api/projection"
+	"github.com/forkfall/backend/internal/domain"
 	"github.com/forkfall/backend/internal/service"
 )
 
@@ -19,42 +23,36 @@ func NewFeedHandler(feedService *service.FeedService) *FeedHandler {
 	}
 }
 
-type FeedResponse struct {
-	Forks      []ForkResponse `json:"forks"`
-	NextCursor string         `json:"next_cursor,omitempty"`
+// SessionResponse is a feed intent. Empty fields match anything.
+type SessionResponse struct {
+	Lane   string `json:"lane"`
+	Energy string `json:"energy"`
+	Mood   string `json:"mood"`
 }
 
-type ForkResponse struct {
-	ID             string   `json:"id"`
-	Prompt         string   `json:"prompt"`
-	LeftLabel      string   `json:"left_label"`
-	RightLabel     string   `json:"right_label"`
-	LeftAssetURL   string   `json:"left_asset_url,omitempty"`
-	RightAssetURL  string   `json:"right_asset_url,omitempty"`
-	IntentLane     string   `json:"intent_lane"`
-	Mood           string   `json:"mood,omitempty"`
-	Energy         string   `json:"energy,omitempty"`
-	ParentForkID   string   `json:"parent_fork_id,omitempty"`
-	MutationType   string   `json:"mutation_type,omitempty"`
-	LeftCount      int      `json:"left_count"`
-	RightCount     int      `json:"right_count"`
-	SkipCount      int      `json:"skip_count"`
-	TwistCount     int      `json:"twist_count"`
-	SafetyAgeGate  string   `json:"safety_age_gate"`
-	SafetyFlags    []string `json:"safety_flags"`
-	CreatedAt      string   `json:"created_at"`
+func newSessionResponse(intent domain.Intent) SessionResponse {
+	return SessionResponse{
+		Lane:   intent.Lane,
+		Energy: intent.Energy,
+		Mood:   intent.Mood,
+	}
+}
+
+// FeedResponse is a page of the feed with the intent it was ranked for
+type FeedResponse struct {
+	Forks      []projection.Fork `json:"forks"`
+	Session    SessionResponse   `json:"session"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
 
 func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
 	actorID, ok := middleware.GetActorID(r.Context())
 	if !ok {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		apierror.Write(w, r, domain.ErrUnauthorized)
 		return
 	}
 
 	// Parse query parameters
-	lane := r.URL.Query().Get("lane")
-	energy := r.URL.Query().Get("energy")
 	cursor := r.URL.Query().Get("cursor")
 	limitStr := r.URL.Query().Get("limit")
 
@@ -65,45 +63,29 @@ func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	session := service.Session{
-		Lane:   lane,
-		Energy: energy,
-	}
-
-	forks, nextCursor, err := h.feedService.GetFeed(r.Context(), actorID, session, cursor, limit)
+	// Query parameters override the stored session field by field
+	intent, err := h.feedService.ResolveIntent(r.Context(), actorID, domain.Intent{
+		Lane:   r.URL.Query().Get("lane"),
+		Energy: r.URL.Query().Get("energy"),
+		Mood:   r.URL.Query().Get("mood"),
+	})
 	if err != nil {
-		http.Error(w, `{"error":"failed to get feed"}`, http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
-	forkResponses := make([]ForkResponse, len(forks))
-	for i, fork := range forks {
-		forkResponses[i] = ForkResponse{
-			ID:            fork.ID.String(),
-			Prompt:        fork.Prompt,
-			LeftLabel:     fork.LeftLabel,
-			RightLabel:    fork.RightLabel,
-			IntentLane:    fork.IntentLane,
-			Mood:          fork.Mood,
-			Energy:        fork.Energy,
-			LeftCount:     fork.LeftCount,
-			RightCount:    fork.RightCount,
-			SkipCount:     fork.SkipCount,
-			TwistCount:    fork.TwistCount,
-			SafetyAgeGate: fork.SafetyAgeGate,
-			SafetyFlags:   fork.SafetyFlags,
-			CreatedAt:     fork.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		}
-		if fork.ParentForkID != nil {
-			forkResponses[i].ParentForkID = fork.ParentForkID.String()
-		}
-		if fork.MutationType != "" {
-			forkResponses[i].MutationType = fork.MutationType
-		}
+	forks, nextCursor, err := h.feedService.GetFeed(r.Context(), actorID, intent, cursor, limit)
+	if errors.Is(err, domain.ErrInvalidInput) {
+		err = &domain.FieldError{Field: "cursor", Err: err}
+	}
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
 	}
 
 	resp := FeedResponse{
-		Forks:      forkResponses,
+		Forks:      projection.NewForks(forks, actorID),
+		Session:    newSessionResponse(intent),
 		NextCursor: nextCursor,
 	}
 
@@ -114,28 +96,48 @@ func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
 type UpdateSessionRequest struct {
 	Lane   string `json:"lane"`
 	Energy string `json:"energy"`
+	Mood   string `json:"mood"`
+}
+
+// GetSession returns the caller's stored feed session
+func (h *FeedHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	session, err := h.feedService.GetSession(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newSessionResponse(*session))
 }
 
 func (h *FeedHandler) UpdateSession(w http.ResponseWriter, r *http.Request) {
 	actorID, ok := middleware.GetActorID(r.Context())
 	if !ok {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		apierror.Write(w, r, domain.ErrUnauthorized)
 		return
 	}
 
 	var req UpdateSessionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody)
 		return
 	}
 
-	session := service.Session{
+	session := domain.Intent{
 		Lane:   req.Lane,
 		Energy: req.Energy,
+		Mood:   req.Mood,
 	}
 
 	if err := h.feedService.UpdateSession(r.Context(), actorID, session); err != nil {
-		http.Error(w, `{"error":"failed to update session"}`, http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -3,8 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/forkfall/backend/internal/api/apierror"
 	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/api/projection"
 	"github.com/forkfall/backend/internal/domain"
 	"github.com/forkfall/backend/internal/service"
 	"github.com/go-chi/chi/v5"
@@ -21,6 +25,8 @@ func NewForkHandler(forkService *service.ForkService) *ForkHandler {
 	}
 }
 
+var errInvalidForkID = apierror.BadRequest("invalid_fork_id", "invalid fork id")
+
 type CreateForkRequest struct {
 	Prompt       string  `json:"prompt"`
 	LeftLabel    string  `json:"left_label"`
@@ -35,29 +41,13 @@ type CreateForkRequest struct {
 func (h *ForkHandler) CreateFork(w http.ResponseWriter, r *http.Request) {
 	actorID, ok := middleware.GetActorID(r.Context())
 	if !ok {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		apierror.Write(w, r, domain.ErrUnauthorized)
 		return
 	}
 
 	var req CreateForkRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
-		return
-	}
-
-	// Validate required fields
-	if req.Prompt == "" || req.LeftLabel == "" || req.RightLabel == "" || req.IntentLane == "" {
-		http.Error(w, `{"error":"prompt, left_label, right_label, and intent_lane are required"}`, http.StatusBadRequest)
-		return
-	}
-
-	// Validate lengths
-	if len(req.Prompt) > 90 {
-		http.Error(w, `{"error":"prompt must be 90 characters or less"}`, http.StatusBadRequest)
-		return
-	}
-	if len(req.LeftLabel) > 24 || len(req.RightLabel) > 24 {
-		http.Error(w, `{"error":"labels must be 24 characters or less"}`, http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody)
 		return
 	}
 
@@ -74,7 +64,7 @@ func (h *ForkHandler) CreateFork(w http.ResponseWriter, r *http.Request) {
 	if req.ParentForkID != nil {
 		parentID, err := uuid.Parse(*req.ParentForkID)
 		if err != nil {
-			http.Error(w, `{"error":"invalid parent_fork_id format"}`, http.StatusBadRequest)
+			apierror.Write(w, r, &domain.FieldError{Field: "parent_fork_id", Err: domain.ErrInvalidInput})
 			return
 		}
 		input.ParentForkID = &parentID
@@ -82,110 +72,218 @@ func (h *ForkHandler) CreateFork(w http.ResponseWriter, r *http.Request) {
 
 	fork, err := h.forkService.CreateFork(r.Context(), actorID, input)
 	if err != nil {
-		http.Error(w, `{"error":"failed to create fork"}`, http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
-	resp := ForkResponse{
-		ID:            fork.ID.String(),
-		Prompt:        fork.Prompt,
-		LeftLabel:     fork.LeftLabel,
-		RightLabel:    fork.RightLabel,
-		IntentLane:    fork.IntentLane,
-		Mood:          fork.Mood,
-		Energy:        fork.Energy,
-		SafetyAgeGate: fork.SafetyAgeGate,
-		SafetyFlags:   fork.SafetyFlags,
-		CreatedAt:     fork.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	if quota, err := h.forkService.GetCreationQuota(r.Context(), actorID); err == nil {
+		setQuotaHeaders(w, quota)
 	}
 
-	if fork.ParentForkID != nil {
-		resp.ParentForkID = fork.ParentForkID.String()
+	resp := projection.NewFork(fork, actorID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+type TwistRequest struct {
+	MutationType string `json:"mutation_type"`
+}
+
+// Twist creates a twist of the fork generated server-side
+func (h *ForkHandler) Twist(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	parentID, err := uuid.Parse(idStr)
+	if err != nil {
+		apierror.Write(w, r, errInvalidForkID)
+		return
+	}
+
+	var req TwistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
 	}
-	if fork.MutationType != "" {
-		resp.MutationType = fork.MutationType
+
+	fork, err := h.forkService.TwistFork(r.Context(), actorID, parentID, req.MutationType)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
 	}
 
+	if quota, err := h.forkService.GetCreationQuota(r.Context(), actorID); err == nil {
+		setQuotaHeaders(w, quota)
+	}
+
+	resp := projection.NewFork(fork, actorID)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(resp)
 }
 
-func (h *ForkHandler) GetFork(w http.ResponseWriter, r *http.Request) {
+type QuotaResponse struct {
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	ResetsAt  string `json:"resets_at,omitempty"`
+}
+
+func (h *ForkHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	quota, err := h.forkService.GetCreationQuota(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := QuotaResponse{
+		Limit:     quota.Limit,
+		Remaining: quota.Remaining,
+	}
+	if !quota.ResetsAt.IsZero() {
+		resp.ResetsAt = quota.ResetsAt.UTC().Format(time.RFC3339)
+	}
+
+	setQuotaHeaders(w, quota)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// setQuotaHeaders exposes the fork creation quota on a response
+func setQuotaHeaders(w http.ResponseWriter, quota *service.CreationQuota) {
+	w.Header().Set("X-Quota-Limit", strconv.Itoa(quota.Limit))
+	w.Header().Set("X-Quota-Remaining", strconv.Itoa(quota.Remaining))
+	if !quota.ResetsAt.IsZero() {
+		w.Header().Set("X-Quota-Reset", strconv.FormatInt(quota.ResetsAt.Unix(), 10))
+	}
+}
+
+type LineageNode struct {
+	projection.Fork
+	Children []LineageNode `json:"children"`
+}
+
+type LineageResponse struct {
+	Ancestors []projection.Fork `json:"ancestors"`
+	Tree      LineageNode       `json:"tree"`
+	Truncated bool              `json:"truncated"`
+}
+
+func newLineageNode(node *domain.ForkNode, viewer uuid.UUID) LineageNode {
+	resp := LineageNode{
+		Fork:     projection.NewFork(node.Fork, viewer),
+		Children: make([]LineageNode, len(node.Children)),
+	}
+	for i, child := range node.Children {
+		resp.Children[i] = newLineageNode(child, viewer)
+	}
+	return resp
+}
+
+func (h *ForkHandler) GetLineage(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, `{"error":"invalid fork id"}`, http.StatusBadRequest)
+		apierror.Write(w, r, errInvalidForkID)
 		return
 	}
 
-	fork, err := h.forkService.GetFork(r.Context(), id)
+	depth := service.LineageDefaultDepth
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 1 || depth > service.LineageMaxDepth {
+			apierror.Write(w, r, &domain.FieldError{Field: "depth", Err: domain.ErrInvalidInput})
+			return
+		}
+	}
+
+	lineage, err := h.forkService.GetLineage(r.Context(), id, depth)
 	if err != nil {
-		http.Error(w, `{"error":"fork not found"}`, http.StatusNotFound)
+		apierror.Write(w, r, err)
 		return
 	}
 
-	resp := ForkResponse{
-		ID:            fork.ID.String(),
-		Prompt:        fork.Prompt,
-		LeftLabel:     fork.LeftLabel,
-		RightLabel:    fork.RightLabel,
-		IntentLane:    fork.IntentLane,
-		Mood:          fork.Mood,
-		Energy:        fork.Energy,
-		LeftCount:     fork.LeftCount,
-		RightCount:    fork.RightCount,
-		SkipCount:     fork.SkipCount,
-		TwistCount:    fork.TwistCount,
-		SafetyAgeGate: fork.SafetyAgeGate,
-		SafetyFlags:   fork.SafetyFlags,
-		CreatedAt:     fork.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	resp := LineageResponse{
+		Ancestors: projection.NewForks(lineage.Ancestors, actorID),
+		Tree:      newLineageNode(lineage.Tree, actorID),
+		Truncated: lineage.Truncated,
 	}
 
-	if fork.ParentForkID != nil {
-		resp.ParentForkID = fork.ParentForkID.String()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ForkHandler) GetFork(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
 	}
-	if fork.MutationType != "" {
-		resp.MutationType = fork.MutationType
+
+	idStr := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		apierror.Write(w, r, errInvalidForkID)
+		return
+	}
+
+	fork, err := h.forkService.GetFork(r.Context(), id)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
 	}
 
+	resp := projection.NewFork(fork, actorID)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
 type InteractRequest struct {
-	Type    string `json:"type"` // swipe_left, swipe_right, skip, twist
+	Type    string `json:"type"` // swipe_left, swipe_right, skip
 	DwellMs int    `json:"dwell_ms,omitempty"`
 }
 
 func (h *ForkHandler) Interact(w http.ResponseWriter, r *http.Request) {
 	actorID, ok := middleware.GetActorID(r.Context())
 	if !ok {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		apierror.Write(w, r, domain.ErrUnauthorized)
 		return
 	}
 
 	idStr := chi.URLParam(r, "id")
 	forkID, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, `{"error":"invalid fork id"}`, http.StatusBadRequest)
+		apierror.Write(w, r, errInvalidForkID)
 		return
 	}
 
 	var req InteractRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody)
 		return
 	}
 
-	validTypes := map[string]bool{
-		"swipe_left":  true,
-		"swipe_right": true,
-		"skip":        true,
-		"twist":       true,
-	}
-	if !validTypes[req.Type] {
-		http.Error(w, `{"error":"invalid interaction type"}`, http.StatusBadRequest)
+	if !domain.ValidInteractionType(req.Type) {
+		apierror.Write(w, r, &domain.FieldError{Field: "type", Err: domain.ErrInvalidInput})
 		return
 	}
 
@@ -196,7 +294,82 @@ func (h *ForkHandler) Interact(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.forkService.RecordInteraction(r.Context(), actorID, input); err != nil {
-		http.Error(w, `{"error":"failed to record interaction"}`, http.StatusInternalServerError)
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+type VoteRequest struct {
+	Type    string `json:"type"` // swipe_left, swipe_right, skip
+	DwellMs int    `json:"dwell_ms,omitempty"`
+}
+
+type VoteResponse struct {
+	ForkID    string `json:"fork_id"`
+	Type      string `json:"type"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func (h *ForkHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	forkID, err := uuid.Parse(idStr)
+	if err != nil {
+		apierror.Write(w, r, errInvalidForkID)
+		return
+	}
+
+	var req VoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody)
+		return
+	}
+
+	if !domain.IsVoteType(req.Type) {
+		apierror.Write(w, r, &domain.FieldError{Field: "type", Err: domain.ErrInvalidInput})
+		return
+	}
+
+	vote, err := h.forkService.CastVote(r.Context(), actorID, forkID, req.Type, req.DwellMs)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := VoteResponse{
+		ForkID:    vote.ForkID.String(),
+		Type:      vote.Type,
+		UpdatedAt: vote.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ForkHandler) RetractVote(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	forkID, err := uuid.Parse(idStr)
+	if err != nil {
+		apierror.Write(w, r, errInvalidForkID)
+		return
+	}
+
+	if err := h.forkService.RetractVote(r.Context(), actorID, forkID); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -211,37 +384,30 @@ type ReportRequest struct {
 func (h *ForkHandler) Report(w http.ResponseWriter, r *http.Request) {
 	actorID, ok := middleware.GetActorID(r.Context())
 	if !ok {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		apierror.Write(w, r, domain.ErrUnauthorized)
 		return
 	}
 
 	idStr := chi.URLParam(r, "id")
 	forkID, err := uuid.Parse(idStr)
 	if err != nil {
-		http.Error(w, `{"error":"invalid fork id"}`, http.StatusBadRequest)
+		apierror.Write(w, r, errInvalidForkID)
 		return
 	}
 
 	var req ReportRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody)
 		return
 	}
 
-	validReasons := map[string]bool{
-		"inappropriate": true,
-		"spam":          true,
-		"harassment":    true,
-		"hate_speech":   true,
-		"other":         true,
-	}
-	if !validReasons[req.Reason] {
-		http.Error(w, `{"error":"invalid report reason"}`, http.StatusBadRequest)
+	if !domain.ValidReportReason(req.Reason) {
+		apierror.Write(w, r, &domain.FieldError{Field: "reason", Err: domain.ErrInvalidInput})
 		return
 	}
 
 	if err := h.forkService.ReportFork(r.Context(), actorID, forkID, req.Reason); err != nil {
-		http.Error(w, `{"error":"failed to submit report"}`, http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
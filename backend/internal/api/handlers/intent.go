@@ -3,6 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/forkfall/backend/internal/domain"
 )
 
 type IntentHandler struct{}
@@ -24,27 +26,24 @@ type IntentsResponse struct {
 	Moods    []IntentOption `json:"moods"`
 }
 
+func newIntentOptions(options []domain.IntentOption) []IntentOption {
+	views := make([]IntentOption, len(options))
+	for i, option := range options {
+		views[i] = IntentOption{
+			ID:          option.ID,
+			Label:       option.Label,
+			Description: option.Description,
+			Emoji:       option.Emoji,
+		}
+	}
+	return views
+}
+
 func (h *IntentHandler) GetIntents(w http.ResponseWriter, r *http.Request) {
 	resp := IntentsResponse{
-		Lanes: []IntentOption{
-			{ID: "discover", Label: "Discover", Description: "Explore new ideas and perspectives", Emoji: "🔍"},
-			{ID: "debate", Label: "Debate", Description: "Engage in friendly arguments", Emoji: "⚔️"},
-			{ID: "vibe", Label: "Vibe", Description: "Light-hearted fun and entertainment", Emoji: "✨"},
-			{ID: "reflect", Label: "Reflect", Description: "Deep thoughts and introspection", Emoji: "🪞"},
-			{ID: "decide", Label: "Decide", Description: "Help making real choices", Emoji: "🎯"},
-		},
-		Energies: []IntentOption{
-			{ID: "chill", Label: "Chill", Description: "Relaxed, low-stakes choices", Emoji: "😌"},
-			{ID: "balanced", Label: "Balanced", Description: "Mix of easy and engaging", Emoji: "⚖️"},
-			{ID: "intense", Label: "Intense", Description: "High-stakes, thought-provoking", Emoji: "🔥"},
-		},
-		Moods: []IntentOption{
-			{ID: "playful", Label: "Playful", Emoji: "😄"},
-			{ID: "serious", Label: "Serious", Emoji: "🤔"},
-			{ID: "spicy", Label: "Spicy", Emoji: "🌶️"},
-			{ID: "wholesome", Label: "Wholesome", Emoji: "💖"},
-			{ID: "chaotic", Label: "Chaotic", Emoji: "🌪️"},
-		},
+		Lanes:    newIntentOptions(domain.IntentLanes),
+		Energies: newIntentOptions(domain.IntentEnergies),
+		Moods:    newIntentOptions(domain.IntentMoods),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
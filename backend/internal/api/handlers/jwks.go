@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/forkfall/backend/internal/jwtkeys"
+)
+
+type JWKSHandler struct {
+	keys *jwtkeys.Manager
+}
+
+func NewJWKSHandler(keys *jwtkeys.Manager) *JWKSHandler {
+	return &JWKSHandler{
+		keys: keys,
+	}
+}
+
+// GetJWKS publishes the public keys access tokens are verified with. Keys are
+// listed before they start signing, so a short cache lifetime is enough for
+// verifiers to pick up a rotation in time.
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.keys.JWKS())
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository/memory"
+	"github.com/forkfall/backend/internal/service"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type noopTrust struct{}
+
+func (noopTrust) Notify(...uuid.UUID) {}
+
+// TestResponsesNeverCarryActorIDs requests every public view of forks as
+// their creator and as someone else, and checks no actor ID ends up in the
+// response and only the creator is told the forks are theirs
+func TestResponsesNeverCarryActorIDs(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	actorRepo := memory.NewActorRepository(store)
+	forkRepo := memory.NewForkRepository(store)
+	interactionRepo := memory.NewInteractionRepository(store)
+
+	creator := domain.NewActor("creator")
+	other := domain.NewActor("other")
+	for _, actor := range []*domain.Actor{creator, other} {
+		if err := actorRepo.Create(ctx, actor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	forkService := service.NewForkService(
+		forkRepo,
+		interactionRepo,
+		actorRepo,
+		memory.NewModerationRepository(store),
+		service.NewMaskService(memory.NewMaskRepository(store)),
+		noopTrust{},
+	)
+	root, err := forkService.CreateFork(ctx, creator.ID, domain.CreateForkInput{
+		Prompt:     "Tea or coffee?",
+		LeftLabel:  "Tea",
+		RightLabel: "Coffee",
+		IntentLane: "vibe",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := forkService.TwistFork(ctx, creator.ID, root.ID, "flip"); err != nil {
+		t.Fatal(err)
+	}
+
+	feedHandler := NewFeedHandler(service.NewFeedService(forkRepo, interactionRepo, memory.NewFeedSessionStore(store), "secret"))
+	forkHandler := NewForkHandler(forkService)
+	profileHandler := NewProfileHandler(service.NewProfileService(actorRepo, forkRepo, interactionRepo))
+
+	serve := func(viewer uuid.UUID, method, path string, body string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), middleware.ActorIDKey, viewer)))
+			})
+		})
+		r.Get("/feed", feedHandler.GetFeed)
+		r.Get("/forks/{id}", forkHandler.GetFork)
+		r.Get("/forks/{id}/lineage", forkHandler.GetLineage)
+		r.Put("/forks/{id}/vote", forkHandler.Vote)
+		r.Get("/me/history", profileHandler.ListVotes)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	// Read the feed before voting, which takes forks out of it
+	paths := []string{"/feed", "/forks/" + root.ID.String(), "/forks/" + root.ID.String() + "/lineage", "/me/history"}
+	for _, viewer := range []*domain.Actor{creator, other} {
+		for _, path := range paths {
+			if path == "/me/history" {
+				rec := serve(viewer.ID, http.MethodPut, "/forks/"+root.ID.String()+"/vote", `{"type":"swipe_left"}`)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("vote as %s: status %d: %s", viewer.DeviceFingerprint, rec.Code, rec.Body)
+				}
+			}
+
+			rec := serve(viewer.ID, http.MethodGet, path, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s as %s: status %d: %s", path, viewer.DeviceFingerprint, rec.Code, rec.Body)
+			}
+			body := rec.Body.String()
+
+			for _, actor := range []*domain.Actor{creator, other} {
+				if strings.Contains(body, actor.ID.String()) {
+					t.Errorf("GET %s as %s leaks the ID of %s: %s", path, viewer.DeviceFingerprint, actor.DeviceFingerprint, body)
+				}
+			}
+
+			flags := createdByYouFlags(t, rec.Body.Bytes())
+			if len(flags) == 0 {
+				t.Errorf("GET %s as %s: no forks in response: %s", path, viewer.DeviceFingerprint, body)
+			}
+			for _, flag := range flags {
+				if flag != (viewer == creator) {
+					t.Errorf("GET %s as %s: created_by_you = %v", path, viewer.DeviceFingerprint, flag)
+				}
+			}
+		}
+	}
+}
+
+// createdByYouFlags collects every created_by_you value in a JSON document
+func createdByYouFlags(t *testing.T, body []byte) []bool {
+	t.Helper()
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var flags []bool
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, value := range v {
+				if flag, ok := value.(bool); ok && key == "created_by_you" {
+					flags = append(flags, flag)
+				}
+				walk(value)
+			}
+		case []any:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	walk(doc)
+	return flags
+}
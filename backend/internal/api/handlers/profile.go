@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/api/projection"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/service"
+)
+
+// ProfileHandler serves the signed-in actor's profile, forks and votes
+type ProfileHandler struct {
+	profileService *service.ProfileService
+}
+
+func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// profileLimit parses the limit query parameter for profile listings
+func profileLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return service.ProfileListDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > service.ProfileListMaxLimit {
+		return 0, &domain.FieldError{Field: "limit", Err: domain.ErrInvalidInput}
+	}
+	return limit, nil
+}
+
+type ProfileResponse struct {
+	ActorID   string               `json:"actor_id"`
+	Status    string               `json:"status"`
+	CreatedAt string               `json:"created_at"`
+	Stats     ProfileStatsResponse `json:"stats"`
+}
+
+// ProfileStatsResponse summarizes the actor's activity. MajorityRate is the
+// share of contested votes that sided with the majority of other voters.
+type ProfileStatsResponse struct {
+	Votes          int     `json:"votes"`
+	ForksCreated   int     `json:"forks_created"`
+	TwistsCreated  int     `json:"twists_created"`
+	ContestedVotes int     `json:"contested_votes"`
+	MajorityVotes  int     `json:"majority_votes"`
+	MajorityRate   float64 `json:"majority_rate"`
+}
+
+// OwnForkResponse is a fork the actor created. Unlike other views it carries
+// the status, so creators can tell when moderation has hidden their fork.
+type OwnForkResponse struct {
+	projection.Fork
+	Status string `json:"status"`
+}
+
+type OwnForkListResponse struct {
+	Forks      []OwnForkResponse `json:"forks"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type VoteHistoryEntry struct {
+	Vote    string          `json:"vote"`
+	VotedAt string          `json:"voted_at"`
+	Fork    projection.Fork `json:"fork"`
+}
+
+type VoteHistoryResponse struct {
+	Votes      []VoteHistoryEntry `json:"votes"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	profile, err := h.profileService.GetProfile(r.Context(), actorID)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	stats := profile.Stats
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ProfileResponse{
+		ActorID:   profile.Actor.ID.String(),
+		Status:    profile.Actor.Status,
+		CreatedAt: profile.Actor.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Stats: ProfileStatsResponse{
+			Votes:          stats.Votes,
+			ForksCreated:   stats.ForksCreated,
+			TwistsCreated:  stats.TwistsCreated,
+			ContestedVotes: stats.ContestedVotes,
+			MajorityVotes:  stats.MajorityVotes,
+			MajorityRate:   stats.MajorityRate(),
+		},
+	})
+}
+
+func (h *ProfileHandler) ListForks(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	limit, err := profileLimit(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	forks, nextCursor, err := h.profileService.ListForks(r.Context(), actorID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := OwnForkListResponse{
+		Forks:      make([]OwnForkResponse, len(forks)),
+		NextCursor: nextCursor,
+	}
+	for i, fork := range forks {
+		resp.Forks[i] = OwnForkResponse{
+			Fork:   projection.NewFork(fork, actorID),
+			Status: fork.Status,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *ProfileHandler) ListVotes(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetActorID(r.Context())
+	if !ok {
+		apierror.Write(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	limit, err := profileLimit(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	votes, nextCursor, err := h.profileService.ListVotes(r.Context(), actorID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resp := VoteHistoryResponse{
+		Votes:      make([]VoteHistoryEntry, len(votes)),
+		NextCursor: nextCursor,
+	}
+	for i, voted := range votes {
+		resp.Votes[i] = VoteHistoryEntry{
+			Vote:    voted.Vote.Type,
+			VotedAt: voted.Vote.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Fork:    projection.NewFork(voted.Fork, actorID),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/forkfall/backend/internal/admintoken"
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/domain"
+)
+
+const StaffKey contextKey = "staff"
+
+var errInvalidAdminToken = apierror.Unauthorized("invalid_admin_token", "invalid admin token")
+
+// AdminAuth authenticates staff on the admin API with signed admin tokens.
+// Actor tokens are never accepted.
+type AdminAuth struct {
+	secret []byte
+}
+
+func NewAdminAuth(secret string) *AdminAuth {
+	return &AdminAuth{secret: []byte(secret)}
+}
+
+func (m *AdminAuth) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "bearer") {
+			apierror.Write(w, r, apierror.Unauthorized("missing_authorization", "missing admin bearer token"))
+			return
+		}
+
+		staff, err := admintoken.Verify(m.secret, token)
+		if err != nil {
+			apierror.Write(w, r, errInvalidAdminToken)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), StaffKey, staff)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireScope only lets staff holding scope through. It must run after
+// AdminAuth.Authenticate.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			staff, ok := GetStaff(r.Context())
+			if !ok {
+				apierror.Write(w, r, errInvalidAdminToken)
+				return
+			}
+			if !staff.HasScope(scope) {
+				apierror.Write(w, r, apierror.Forbidden("missing_scope", "admin token lacks scope "+scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetStaff extracts the authenticated staff member from the request context
+func GetStaff(ctx context.Context) (*domain.Staff, bool) {
+	staff, ok := ctx.Value(StaffKey).(*domain.Staff)
+	return staff, ok
+}
@@ -2,24 +2,48 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/jwtkeys"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type contextKey string
 
-const ActorIDKey contextKey = "actor_id"
+const (
+	ActorIDKey   contextKey = "actor_id"
+	ActorKey     contextKey = "actor"
+	SessionIDKey contextKey = "session_id"
+)
+
+// ActorResolver loads the actor a token was issued to
+type ActorResolver interface {
+	ResolveActor(ctx context.Context, actorID uuid.UUID) (*domain.Actor, error)
+}
+
+// SessionChecker reports whether a sign-in session has been revoked
+type SessionChecker interface {
+	IsSessionRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+var errInvalidToken = apierror.Unauthorized("invalid_token", "invalid token")
 
 type AuthMiddleware struct {
-	jwtSecret []byte
+	keys     *jwtkeys.Manager
+	actors   ActorResolver
+	sessions SessionChecker
 }
 
-func NewAuthMiddleware(jwtSecret string) *AuthMiddleware {
+func NewAuthMiddleware(keys *jwtkeys.Manager, actors ActorResolver, sessions SessionChecker) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtSecret: []byte(jwtSecret),
+		keys:     keys,
+		actors:   actors,
+		sessions: sessions,
 	}
 }
 
@@ -27,50 +51,87 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, `{"error":"missing authorization header"}`, http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized("missing_authorization", "missing authorization header"))
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, `{"error":"invalid authorization header format"}`, http.StatusUnauthorized)
+			apierror.Write(w, r, apierror.Unauthorized("invalid_authorization", "invalid authorization header format"))
 			return
 		}
 
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return m.jwtSecret, nil
-		})
+		token, err := jwt.Parse(tokenString, m.keys.Keyfunc, jwt.WithValidMethods(m.keys.Algorithms()), jwt.WithExpirationRequired())
 
 		if err != nil || !token.Valid {
-			http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+			apierror.Write(w, r, errInvalidToken)
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			http.Error(w, `{"error":"invalid token claims"}`, http.StatusUnauthorized)
+			apierror.Write(w, r, errInvalidToken)
 			return
 		}
 
 		actorIDStr, ok := claims["actor_id"].(string)
 		if !ok {
-			http.Error(w, `{"error":"missing actor_id in token"}`, http.StatusUnauthorized)
+			apierror.Write(w, r, errInvalidToken)
 			return
 		}
 
 		actorID, err := uuid.Parse(actorIDStr)
 		if err != nil {
-			http.Error(w, `{"error":"invalid actor_id format"}`, http.StatusUnauthorized)
+			apierror.Write(w, r, errInvalidToken)
+			return
+		}
+
+		// Tokens issued before sessions existed carry no sid and can't be
+		// revoked, so they are not accepted
+		sessionIDStr, ok := claims["sid"].(string)
+		if !ok {
+			apierror.Write(w, r, errInvalidToken)
+			return
+		}
+
+		sessionID, err := uuid.Parse(sessionIDStr)
+		if err != nil {
+			apierror.Write(w, r, errInvalidToken)
+			return
+		}
+
+		revoked, err := m.sessions.IsSessionRevoked(r.Context(), sessionID)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+		if revoked {
+			apierror.Write(w, r, domain.ErrSessionRevoked)
+			return
+		}
+
+		// Reject actors that no longer exist or have been suspended/banned
+		// since the token was issued
+		actor, err := m.actors.ResolveActor(r.Context(), actorID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				apierror.Write(w, r, apierror.Unauthorized("actor_not_found", "actor not found"))
+				return
+			}
+			apierror.Write(w, r, err)
+			return
+		}
+		if err := actor.CheckActive(); err != nil {
+			apierror.Write(w, r, err)
 			return
 		}
 
-		// Add actor ID to context
+		// Add actor to context
 		ctx := context.WithValue(r.Context(), ActorIDKey, actorID)
+		ctx = context.WithValue(ctx, ActorKey, actor)
+		ctx = context.WithValue(ctx, SessionIDKey, sessionID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -80,3 +141,15 @@ func GetActorID(ctx context.Context) (uuid.UUID, bool) {
 	actorID, ok := ctx.Value(ActorIDKey).(uuid.UUID)
 	return actorID, ok
 }
+
+// GetActor extracts the resolved actor from the request context
+func GetActor(ctx context.Context) (*domain.Actor, bool) {
+	actor, ok := ctx.Value(ActorKey).(*domain.Actor)
+	return actor, ok
+}
+
+// GetSessionID extracts the sign-in session ID from the request context
+func GetSessionID(ctx context.Context) (uuid.UUID, bool) {
+	sessionID, ok := ctx.Value(SessionIDKey).(uuid.UUID)
+	return sessionID, ok
+}
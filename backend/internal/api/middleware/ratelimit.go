@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forkfall/backend/internal/api/apierror"
+	"github.com/forkfall/backend/internal/clock"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript records a hit in a sorted set of timestamps if fewer
+// than limit hits fall inside the window, and reports the resulting count and
+// the oldest hit still in the window.
+//
+// KEYS[1] = bucket key
+// ARGV    = now (ms), window (ms), limit, unique member
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local oldest = now
+local first = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if first[2] then
+	oldest = tonumber(first[2])
+end
+
+return {allowed, count, oldest}
+`)
+
+// KeyFunc identifies who a request is rate limited as
+type KeyFunc func(r *http.Request) string
+
+// KeyByActor limits authenticated requests per actor, falling back to the
+// client IP when no actor is on the context.
+func KeyByActor(r *http.Request) string {
+	if actorID, ok := GetActorID(r.Context()); ok {
+		return "actor:" + actorID.String()
+	}
+	return KeyByIP(r)
+}
+
+// KeyByIP limits requests per client IP. It relies on RealIP having already
+// rewritten RemoteAddr for requests from trusted proxies.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RatePolicy is a per-route limit of Limit requests per sliding Window
+type RatePolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    KeyFunc
+}
+
+// RateStore keeps the hits of each rate limit bucket
+type RateStore interface {
+	// Hit records a hit at now in the bucket unless limit hits already fall
+	// within the window ending at now, and returns whether it was recorded,
+	// the number of hits now in the window and when the oldest was made
+	Hit(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (allowed bool, count int, oldest time.Time, err error)
+}
+
+// RedisRateStore keeps buckets in Redis sorted sets, shared by every server
+type RedisRateStore struct {
+	redis *redis.Client
+}
+
+func NewRedisRateStore(redis *redis.Client) *RedisRateStore {
+	return &RedisRateStore{redis: redis}
+}
+
+func (s *RedisRateStore) Hit(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (bool, int, time.Time, error) {
+	res, err := slidingWindowScript.Run(ctx, s.redis, []string{key},
+		now.UnixMilli(),
+		window.Milliseconds(),
+		limit,
+		uuid.NewString(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, time.Time{}, err
+	}
+	if len(res) != 3 {
+		return false, 0, time.Time{}, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+	return res[0] == 1, int(res[1]), time.UnixMilli(res[2]), nil
+}
+
+// MemoryRateStore keeps buckets in process, with the same semantics as
+// RedisRateStore. It is meant for a single server and for tests.
+type MemoryRateStore struct {
+	mu      sync.Mutex
+	buckets map[string][]time.Time
+}
+
+func NewMemoryRateStore() *MemoryRateStore {
+	return &MemoryRateStore{buckets: make(map[string][]time.Time)}
+}
+
+func (s *MemoryRateStore) Hit(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (bool, int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Hits are kept oldest first; drop those at or before the window start
+	hits := s.buckets[key]
+	start := now.Add(-window)
+	expired := 0
+	for expired < len(hits) && !hits[expired].After(start) {
+		expired++
+	}
+	hits = hits[expired:]
+
+	allowed := len(hits) < limit
+	if allowed {
+		hits = append(hits, now)
+	}
+	if len(hits) == 0 {
+		delete(s.buckets, key)
+		return allowed, 0, now, nil
+	}
+	s.buckets[key] = hits
+	return allowed, len(hits), hits[0], nil
+}
+
+type RateLimiter struct {
+	store RateStore
+	clock clock.Clock
+}
+
+func NewRateLimiter(store RateStore, clock clock.Clock) *RateLimiter {
+	return &RateLimiter{
+		store: store,
+		clock: clock,
+	}
+}
+
+// Limit enforces policy on the wrapped handler and reports usage through the
+// X-RateLimit-* headers. Store failures are logged and the request is let
+// through rather than taking the route down.
+func (l *RateLimiter) Limit(policy RatePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := l.clock.Now()
+			key := "ratelimit:" + policy.Name + ":" + policy.Key(r)
+
+			allowed, count, oldest, err := l.store.Hit(r.Context(), key, now, policy.Window, policy.Limit)
+			if err != nil {
+				log.Printf("Rate limiter %s unavailable: %v", policy.Name, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			resetAt := oldest.Add(policy.Window)
+
+			remaining := policy.Limit - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+			if !allowed {
+				apierror.Write(w, r, &domain.RateLimitError{RetryAfter: resetAt.Sub(now)})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
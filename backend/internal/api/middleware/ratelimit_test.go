@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/forkfall/backend/internal/clock"
+)
+
+func newLimitedHandler(clk clock.Clock, trusted []netip.Prefix) http.Handler {
+	limiter := NewRateLimiter(NewMemoryRateStore(), clk)
+	policy := RatePolicy{Name: "test", Limit: 3, Window: time.Minute, Key: KeyByIP}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return RealIP(trusted)(limiter.Limit(policy)(ok))
+}
+
+func hit(h http.Handler, remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func checkHeaders(t *testing.T, rec *httptest.ResponseRecorder, remaining int, reset time.Time) {
+	t.Helper()
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "3" {
+		t.Errorf("X-RateLimit-Limit = %q, want 3", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(remaining) {
+		t.Errorf("X-RateLimit-Remaining = %q, want %d", got, remaining)
+	}
+	if got := rec.Header().Get("X-RateLimit-Reset"); got != strconv.FormatInt(reset.Unix(), 10) {
+		t.Errorf("X-RateLimit-Reset = %q, want %d", got, reset.Unix())
+	}
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	t0 := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(t0)
+	h := newLimitedHandler(clk, nil)
+	const client = "203.0.113.7:51000"
+
+	for i := 0; i < 3; i++ {
+		rec := hit(h, client, "")
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("hit %d: status %d, want %d", i+1, rec.Code, http.StatusNoContent)
+		}
+		checkHeaders(t, rec, 2-i, t0.Add(time.Minute))
+		clk.Advance(10 * time.Second)
+	}
+
+	// The fourth hit inside the window is refused until the first one leaves it
+	rec := hit(h, client, "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("hit 4: status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	checkHeaders(t, rec, 0, t0.Add(time.Minute))
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+
+	// Once the first hit has slid out, one more fits and the window now
+	// resets when the second hit leaves it
+	clk.Set(t0.Add(time.Minute + time.Millisecond))
+	rec = hit(h, client, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("hit after reset: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	checkHeaders(t, rec, 0, t0.Add(70*time.Second))
+
+	// Other clients have their own bucket
+	rec = hit(h, "198.51.100.4:40000", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("other client: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	checkHeaders(t, rec, 2, t0.Add(2*time.Minute+time.Millisecond))
+}
+
+func TestRateLimitIgnoresUntrustedForwardingHeaders(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	h := newLimitedHandler(clk, nil)
+	const client = "203.0.113.7:51000"
+
+	for i := 0; i < 3; i++ {
+		hit(h, client, "")
+	}
+
+	// A client naming someone else in X-Forwarded-For stays in its own bucket
+	rec := hit(h, client, "192.0.2.99")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed X-Forwarded-For: status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitKeysOnClientBehindTrustedProxy(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk := clock.NewFake(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	h := newLimitedHandler(clk, trusted)
+
+	// Whatever the client prepends, the rightmost untrusted hop is the client
+	for i, forwarded := range []string{"203.0.113.7", "192.0.2.1, 203.0.113.7", "192.0.2.2, 203.0.113.7, 10.1.2.3"} {
+		rec := hit(h, "192.168.1.1:443", forwarded)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("hit %d: status %d, want %d", i+1, rec.Code, http.StatusNoContent)
+		}
+	}
+	rec := hit(h, "10.0.0.5:443", "192.0.2.3, 203.0.113.7")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("hit 4: status %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	// Another client behind the same proxy isn't limited with it
+	rec = hit(h, "192.168.1.1:443", "198.51.100.4")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("other client: status %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestParseTrustedProxiesRejectsGarbage(t *testing.T) {
+	if _, err := ParseTrustedProxies("10.0.0.0/8, proxy.internal"); err == nil {
+		t.Error("expected an error for a hostname")
+	}
+	prefixes, err := ParseTrustedProxies("")
+	if err != nil || len(prefixes) != 0 {
+		t.Errorf("empty list = %v, %v; want no prefixes", prefixes, err)
+	}
+}
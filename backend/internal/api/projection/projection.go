@@ -0,0 +1,76 @@
+// Package projection builds the views of domain objects that are sent to
+// clients. Public views never carry actor IDs, which would link everything an
+// actor has created: content is attributed to the creator's mask handle, and
+// a viewer only learns whether they created something themselves. Admin
+// responses add identity on top of these views explicitly.
+package projection
+
+import (
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Fork is the public view of a fork
+type Fork struct {
+	ID            string               `json:"id"`
+	Prompt        string               `json:"prompt"`
+	LeftLabel     string               `json:"left_label"`
+	RightLabel    string               `json:"right_label"`
+	LeftAssetURL  string               `json:"left_asset_url,omitempty"`
+	RightAssetURL string               `json:"right_asset_url,omitempty"`
+	IntentLane    string               `json:"intent_lane"`
+	Mood          string               `json:"mood,omitempty"`
+	Energy        string               `json:"energy,omitempty"`
+	ParentForkID  string               `json:"parent_fork_id,omitempty"`
+	MutationType  string               `json:"mutation_type,omitempty"`
+	MutationDiff  *domain.MutationDiff `json:"mutation_diff,omitempty"`
+	MaskHandle    string               `json:"mask_handle,omitempty"`
+	CreatedByYou  bool                 `json:"created_by_you"`
+	LeftCount     int                  `json:"left_count"`
+	RightCount    int                  `json:"right_count"`
+	SkipCount     int                  `json:"skip_count"`
+	TwistCount    int                  `json:"twist_count"`
+	SafetyAgeGate string               `json:"safety_age_gate"`
+	SafetyFlags   []string             `json:"safety_flags"`
+	CreatedAt     string               `json:"created_at"`
+}
+
+// NewFork projects a fork for the given viewer. Pass uuid.Nil when there is
+// no viewing actor.
+func NewFork(fork *domain.Fork, viewer uuid.UUID) Fork {
+	view := Fork{
+		ID:            fork.ID.String(),
+		Prompt:        fork.Prompt,
+		LeftLabel:     fork.LeftLabel,
+		RightLabel:    fork.RightLabel,
+		IntentLane:    fork.IntentLane,
+		Mood:          fork.Mood,
+		Energy:        fork.Energy,
+		MaskHandle:    fork.MaskHandle,
+		CreatedByYou:  viewer != uuid.Nil && fork.CreatedByActorID == viewer,
+		LeftCount:     fork.LeftCount,
+		RightCount:    fork.RightCount,
+		SkipCount:     fork.SkipCount,
+		TwistCount:    fork.TwistCount,
+		SafetyAgeGate: fork.SafetyAgeGate,
+		SafetyFlags:   fork.SafetyFlags,
+		CreatedAt:     fork.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+	if fork.ParentForkID != nil {
+		view.ParentForkID = fork.ParentForkID.String()
+	}
+	if fork.MutationType != "" {
+		view.MutationType = fork.MutationType
+		view.MutationDiff = fork.MutationDiff
+	}
+	return view
+}
+
+// NewForks projects a list of forks for the given viewer
+func NewForks(forks []*domain.Fork, viewer uuid.UUID) []Fork {
+	views := make([]Fork, len(forks))
+	for i, fork := range forks {
+		views[i] = NewFork(fork, viewer)
+	}
+	return views
+}
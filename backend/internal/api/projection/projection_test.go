@@ -0,0 +1,82 @@
+package projection
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+func testFork(creator uuid.UUID) *domain.Fork {
+	parent := uuid.New()
+	mask := uuid.New()
+	return &domain.Fork{
+		ID:               uuid.New(),
+		Prompt:           "Tea or coffee?",
+		LeftLabel:        "Tea",
+		RightLabel:       "Coffee",
+		IntentLane:       "vibe",
+		ParentForkID:     &parent,
+		MutationType:     "flip",
+		MutationDiff:     &domain.MutationDiff{},
+		SafetyAgeGate:    domain.AgeGateAll,
+		SafetyFlags:      []string{},
+		Status:           domain.ForkStatusVisible,
+		CreatedByActorID: creator,
+		CreatedByMaskID:  &mask,
+		MaskHandle:       "quiet-otter-17",
+		CreatedAt:        time.Now(),
+	}
+}
+
+func TestForkViewsNeverCarryActorIDs(t *testing.T) {
+	creator := uuid.New()
+	other := uuid.New()
+	forks := []*domain.Fork{testFork(creator), testFork(creator)}
+
+	viewers := []struct {
+		name         string
+		viewer       uuid.UUID
+		createdByYou bool
+	}{
+		{"anonymous", uuid.Nil, false},
+		{"creator", creator, true},
+		{"other", other, false},
+	}
+	for _, tc := range viewers {
+		t.Run(tc.name, func(t *testing.T) {
+			single, err := json.Marshal(NewFork(forks[0], tc.viewer))
+			if err != nil {
+				t.Fatal(err)
+			}
+			list, err := json.Marshal(NewForks(forks, tc.viewer))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, body := range [][]byte{single, list} {
+				for _, id := range []uuid.UUID{creator, other, *forks[0].CreatedByMaskID, *forks[1].CreatedByMaskID} {
+					if strings.Contains(string(body), id.String()) {
+						t.Errorf("view contains actor or mask ID %s: %s", id, body)
+					}
+				}
+			}
+
+			var views []map[string]any
+			if err := json.Unmarshal(list, &views); err != nil {
+				t.Fatal(err)
+			}
+			for _, view := range views {
+				if got := view["created_by_you"]; got != tc.createdByYou {
+					t.Errorf("created_by_you = %v, want %v", got, tc.createdByYou)
+				}
+				if got := view["mask_handle"]; got != "quiet-otter-17" {
+					t.Errorf("mask_handle = %v, want quiet-otter-17", got)
+				}
+			}
+		})
+	}
+}
@@ -2,27 +2,48 @@ package api
 
 import (
 	"net/http"
+	"net/netip"
 	"time"
 
+	"github.com/forkfall/backend/internal/api/apierror"
 	"github.com/forkfall/backend/internal/api/handlers"
 	"github.com/forkfall/backend/internal/api/middleware"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/jwtkeys"
 	"github.com/forkfall/backend/internal/service"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 )
 
+// Per-route rate limit policies
+var (
+	deviceAuthLimit = middleware.RatePolicy{Name: "auth_device", Limit: 10, Window: time.Minute, Key: middleware.KeyByIP}
+	challengeLimit  = middleware.RatePolicy{Name: "auth_challenge", Limit: 10, Window: time.Minute, Key: middleware.KeyByIP}
+	refreshLimit    = middleware.RatePolicy{Name: "auth_refresh", Limit: 30, Window: time.Minute, Key: middleware.KeyByIP}
+	swipeLimit      = middleware.RatePolicy{Name: "swipe", Limit: 100, Window: time.Minute, Key: middleware.KeyByActor}
+	reportLimit     = middleware.RatePolicy{Name: "report", Limit: 20, Window: time.Hour, Key: middleware.KeyByActor}
+	exportLimit     = middleware.RatePolicy{Name: "account_export", Limit: 5, Window: time.Hour, Key: middleware.KeyByActor}
+	accountJobLimit = middleware.RatePolicy{Name: "account_job", Limit: 60, Window: time.Minute, Key: middleware.KeyByIP}
+)
+
 func NewRouter(
 	authService *service.AuthService,
 	feedService *service.FeedService,
 	forkService *service.ForkService,
-	jwtSecret string,
+	adminService *service.AdminService,
+	accountService *service.AccountService,
+	profileService *service.ProfileService,
+	rateLimiter *middleware.RateLimiter,
+	trustedProxies []netip.Prefix,
+	keys *jwtkeys.Manager,
+	adminSecret string,
 ) http.Handler {
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.RealIP(trustedProxies))
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.Timeout(30 * time.Second))
@@ -32,19 +53,32 @@ func NewRouter(
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	// Unmatched routes use the same error envelope as handlers
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, apierror.NotFound("route_not_found", "route not found"))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed"))
+	})
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
+	deviceHandler := handlers.NewDeviceHandler(authService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	profileHandler := handlers.NewProfileHandler(profileService)
 	feedHandler := handlers.NewFeedHandler(feedService)
 	forkHandler := handlers.NewForkHandler(forkService)
 	intentHandler := handlers.NewIntentHandler()
+	adminHandler := handlers.NewAdminHandler(adminService)
+	jwksHandler := handlers.NewJWKSHandler(keys)
 
 	// Auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtSecret)
+	authMiddleware := middleware.NewAuthMiddleware(keys, authService, authService)
 
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -52,31 +86,88 @@ func NewRouter(
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
+	// Public keys for verifying access tokens
+	r.Get("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// API v1 routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Public routes
-		r.Post("/auth/device", authHandler.DeviceAuth)
+		r.With(rateLimiter.Limit(challengeLimit)).Post("/auth/challenge", authHandler.Challenge)
+		r.With(rateLimiter.Limit(deviceAuthLimit)).Post("/auth/device", authHandler.DeviceAuth)
+		r.With(rateLimiter.Limit(refreshLimit)).Post("/auth/refresh", authHandler.Refresh)
+		r.With(rateLimiter.Limit(accountJobLimit)).Get("/account-jobs/{id}", accountHandler.GetJob)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
 
+			// Auth
+			r.Post("/auth/logout", authHandler.Logout)
+
+			// Account
+			r.Get("/me", profileHandler.GetProfile)
+			r.Get("/me/forks", profileHandler.ListForks)
+			r.Get("/me/history", profileHandler.ListVotes)
+			r.Delete("/me", accountHandler.DeleteAccount)
+			r.With(rateLimiter.Limit(exportLimit)).Post("/me/export", accountHandler.RequestExport)
+			r.Get("/me/export", accountHandler.GetExport)
+
+			// Devices
+			r.Get("/me/devices", deviceHandler.ListDevices)
+			r.Post("/me/devices/link-code", deviceHandler.CreateLinkCode)
+			r.Delete("/me/devices/{id}", deviceHandler.UnlinkDevice)
+			r.Post("/me/recovery-code", deviceHandler.RegenerateRecoveryCode)
+
 			// Feed
 			r.Get("/feed", feedHandler.GetFeed)
 
 			// Forks
 			r.Post("/forks", forkHandler.CreateFork)
+			r.Get("/forks/quota", forkHandler.GetQuota)
 			r.Get("/forks/{id}", forkHandler.GetFork)
-			r.Post("/forks/{id}/interact", forkHandler.Interact)
-			r.Post("/forks/{id}/report", forkHandler.Report)
+			r.Get("/forks/{id}/lineage", forkHandler.GetLineage)
+			r.Post("/forks/{id}/twist", forkHandler.Twist)
+			r.With(rateLimiter.Limit(swipeLimit)).Post("/forks/{id}/interact", forkHandler.Interact)
+			r.With(rateLimiter.Limit(swipeLimit)).Put("/forks/{id}/vote", forkHandler.Vote)
+			r.With(rateLimiter.Limit(swipeLimit)).Delete("/forks/{id}/vote", forkHandler.RetractVote)
+			r.With(rateLimiter.Limit(reportLimit)).Post("/forks/{id}/report", forkHandler.Report)
 
 			// Intents
 			r.Get("/intents", intentHandler.GetIntents)
 
 			// Session
+			r.Get("/session", feedHandler.GetSession)
 			r.Put("/session", feedHandler.UpdateSession)
 		})
 	})
 
+	// Admin API, only served when an admin token secret is configured
+	if adminSecret != "" {
+		adminAuth := middleware.NewAdminAuth(adminSecret)
+		scope := middleware.RequireScope
+
+		r.Route("/admin/v1", func(r chi.Router) {
+			r.Use(adminAuth.Authenticate)
+
+			// Actors
+			r.With(scope(domain.ScopeActorsRead)).Get("/actors", adminHandler.FindActor)
+			r.With(scope(domain.ScopeActorsRead)).Get("/actors/{id}", adminHandler.GetActor)
+			r.With(scope(domain.ScopeActorsWrite)).Put("/actors/{id}/status", adminHandler.SetActorStatus)
+			r.With(scope(domain.ScopeActorsWrite)).Put("/actors/{id}/trust", adminHandler.SetTrustScore)
+
+			// Reports
+			r.With(scope(domain.ScopeReportsRead)).Get("/reports", adminHandler.ListReports)
+			r.With(scope(domain.ScopeReportsWrite)).Put("/reports/{id}", adminHandler.ResolveReport)
+
+			// Forks
+			r.With(scope(domain.ScopeForksRead)).Get("/forks/{id}", adminHandler.GetFork)
+			r.With(scope(domain.ScopeForksWrite)).Post("/forks/{id}/remove", adminHandler.RemoveFork)
+			r.With(scope(domain.ScopeForksWrite)).Post("/forks/{id}/restore", adminHandler.RestoreFork)
+
+			// Audit log
+			r.With(scope(domain.ScopeAuditRead)).Get("/audit", adminHandler.ListAudit)
+		})
+	}
+
 	return r
 }
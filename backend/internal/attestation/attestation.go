@@ -0,0 +1,83 @@
+// Package attestation checks platform attestations of device keys: signed
+// statements from the OS or hardware that a key was generated on a genuine
+// device and bound to a sign-in challenge. Each attestation format has its
+// own Verifier, registered with a Registry under the format's name.
+package attestation
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/forkfall/backend/internal/domain"
+)
+
+// Verifier checks attestation statements of one format
+type Verifier interface {
+	// Verify checks that statement attests publicKey and is bound to
+	// challenge. Returns domain.ErrInvalidAttestation if it does not.
+	Verify(ctx context.Context, publicKey, challenge, statement []byte) error
+}
+
+// Registry dispatches attestation statements to the verifier for their
+// format
+type Registry struct {
+	verifiers map[string]Verifier
+	required  bool
+}
+
+// NewRegistry creates an empty registry. When required is set, device keys
+// without an attestation are refused.
+func NewRegistry(required bool) *Registry {
+	return &Registry{
+		verifiers: make(map[string]Verifier),
+		required:  required,
+	}
+}
+
+// Register makes verifier handle statements of format
+func (r *Registry) Register(format string, verifier Verifier) {
+	r.verifiers[format] = verifier
+}
+
+// Check verifies a statement of the given format. An empty format means the
+// client sent no attestation, which is accepted unless attestation is
+// required.
+func (r *Registry) Check(ctx context.Context, format string, publicKey, challenge, statement []byte) error {
+	if format == "" {
+		if r.required {
+			return domain.ErrAttestationRequired
+		}
+		return nil
+	}
+
+	verifier, ok := r.verifiers[format]
+	if !ok {
+		return &domain.FieldError{Field: "attestation.format", Err: domain.ErrInvalidInput}
+	}
+	return verifier.Verify(ctx, publicKey, challenge, statement)
+}
+
+// FakeFormat is the format name of Fake attestations
+const FakeFormat = "fake"
+
+// Fake stands in for platform attestation in development. Its statement is
+// FakeStatement of the key and challenge: it proves nothing about the
+// device, but makes clients exercise the same binding a real format has.
+type Fake struct{}
+
+// FakeStatement computes the statement Fake accepts
+func FakeStatement(publicKey, challenge []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("forkfall-fake-attestation:"))
+	h.Write(challenge)
+	h.Write(publicKey)
+	return h.Sum(nil)
+}
+
+func (Fake) Verify(ctx context.Context, publicKey, challenge, statement []byte) error {
+	if subtle.ConstantTimeCompare(statement, FakeStatement(publicKey, challenge)) != 1 {
+		return domain.ErrInvalidAttestation
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeletedActorID is the placeholder actor that takes over forks which
+// outlive their creator's account: forks others have twisted, whose lineage
+// would break without them, and forks moderation has acted on, whose audit
+// trail must stay intact. Every other fork is deleted with its account.
+var DeletedActorID = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+
+// Account job kinds
+const (
+	AccountJobExport = "export"
+	AccountJobDelete = "delete"
+)
+
+// Account job statuses
+const (
+	AccountJobPending   = "pending"
+	AccountJobRunning   = "running"
+	AccountJobCompleted = "completed"
+	AccountJobFailed    = "failed"
+)
+
+// Account job timing. A running job not finished within AccountJobTimeout
+// is assumed abandoned by a crashed worker and is picked up again. Finished
+// jobs, including export archives, are kept for AccountJobRetention.
+const (
+	AccountJobTimeout   = 10 * time.Minute
+	AccountJobRetention = 7 * 24 * time.Hour
+)
+
+// AccountJob is an export or deletion of an actor's account, carried out in
+// the background. Jobs are not tied to the actor's lifetime, so a deletion
+// job can still be polled after the actor is gone.
+type AccountJob struct {
+	ID      uuid.UUID
+	ActorID uuid.UUID
+	Kind    string
+	Status  string
+	// Archive is the JSON-encoded AccountArchive of a completed export
+	Archive     []byte
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// NewAccountJob creates a pending job of the given kind
+func NewAccountJob(actorID uuid.UUID, kind string) *AccountJob {
+	return &AccountJob{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		Kind:      kind,
+		Status:    AccountJobPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Finished reports whether the job has completed or failed
+func (j *AccountJob) Finished() bool {
+	return j.Status == AccountJobCompleted || j.Status == AccountJobFailed
+}
+
+// AccountData is everything stored about an actor that an export covers
+type AccountData struct {
+	Actor    *Actor
+	Devices  []*Device
+	Sessions []*Session
+	Forks    []*Fork
+	Votes    []*Vote
+	Reports  []*Report
+}
+
+// AccountArchive is the document an export produces
+type AccountArchive struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	Actor      ArchivedActor     `json:"actor"`
+	Devices    []ArchivedDevice  `json:"devices"`
+	Sessions   []ArchivedSession `json:"sessions"`
+	Forks      []ArchivedFork    `json:"forks"`
+	Votes      []ArchivedVote    `json:"votes"`
+	Reports    []ArchivedReport  `json:"reports"`
+}
+
+type ArchivedActor struct {
+	ID         uuid.UUID `json:"id"`
+	Status     string    `json:"status"`
+	TrustScore float64   `json:"trust_score"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ArchivedDevice struct {
+	ID                uuid.UUID `json:"id"`
+	Fingerprint       string    `json:"fingerprint"`
+	AttestationFormat string    `json:"attestation_format,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	LastSeenAt        time.Time `json:"last_seen_at"`
+}
+
+type ArchivedSession struct {
+	ID        uuid.UUID  `json:"id"`
+	DeviceID  *uuid.UUID `json:"device_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type ArchivedFork struct {
+	ID           uuid.UUID  `json:"id"`
+	Prompt       string     `json:"prompt"`
+	LeftLabel    string     `json:"left_label"`
+	RightLabel   string     `json:"right_label"`
+	IntentLane   string     `json:"intent_lane"`
+	Mood         string     `json:"mood,omitempty"`
+	Energy       string     `json:"energy,omitempty"`
+	ParentForkID *uuid.UUID `json:"parent_fork_id,omitempty"`
+	MutationType string     `json:"mutation_type,omitempty"`
+	Status       string     `json:"status"`
+	MaskHandle   string     `json:"mask_handle,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ArchivedVote struct {
+	ForkID    uuid.UUID `json:"fork_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ArchivedReport struct {
+	ID        uuid.UUID `json:"id"`
+	ForkID    uuid.UUID `json:"fork_id"`
+	Reason    string    `json:"reason"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewAccountArchive lays out the actor's data for export. Times are in UTC.
+func NewAccountArchive(data *AccountData, exportedAt time.Time) *AccountArchive {
+	archive := &AccountArchive{
+		ExportedAt: exportedAt.UTC(),
+		Actor: ArchivedActor{
+			ID:         data.Actor.ID,
+			Status:     data.Actor.Status,
+			TrustScore: data.Actor.TrustScore,
+			CreatedAt:  data.Actor.CreatedAt.UTC(),
+		},
+		Devices:  make([]ArchivedDevice, len(data.Devices)),
+		Sessions: make([]ArchivedSession, len(data.Sessions)),
+		Forks:    make([]ArchivedFork, len(data.Forks)),
+		Votes:    make([]ArchivedVote, len(data.Votes)),
+		Reports:  make([]ArchivedReport, len(data.Reports)),
+	}
+	for i, d := range data.Devices {
+		archive.Devices[i] = ArchivedDevice{
+			ID:                d.ID,
+			Fingerprint:       d.Fingerprint,
+			AttestationFormat: d.AttestationFormat,
+			CreatedAt:         d.CreatedAt.UTC(),
+			LastSeenAt:        d.LastSeenAt.UTC(),
+		}
+	}
+	for i, s := range data.Sessions {
+		archive.Sessions[i] = ArchivedSession{
+			ID:        s.ID,
+			DeviceID:  s.DeviceID,
+			CreatedAt: s.CreatedAt.UTC(),
+		}
+		if s.RevokedAt != nil {
+			revokedAt := s.RevokedAt.UTC()
+			archive.Sessions[i].RevokedAt = &revokedAt
+		}
+	}
+	for i, f := range data.Forks {
+		archive.Forks[i] = ArchivedFork{
+			ID:           f.ID,
+			Prompt:       f.Prompt,
+			LeftLabel:    f.LeftLabel,
+			RightLabel:   f.RightLabel,
+			IntentLane:   f.IntentLane,
+			Mood:         f.Mood,
+			Energy:       f.Energy,
+			ParentForkID: f.ParentForkID,
+			MutationType: f.MutationType,
+			Status:       f.Status,
+			MaskHandle:   f.MaskHandle,
+			CreatedAt:    f.CreatedAt.UTC(),
+		}
+	}
+	for i, v := range data.Votes {
+		archive.Votes[i] = ArchivedVote{
+			ForkID:    v.ForkID,
+			Type:      v.Type,
+			CreatedAt: v.CreatedAt.UTC(),
+			UpdatedAt: v.UpdatedAt.UTC(),
+		}
+	}
+	for i, r := range data.Reports {
+		archive.Reports[i] = ArchivedReport{
+			ID:        r.ID,
+			ForkID:    r.ForkID,
+			Reason:    r.Reason,
+			State:     r.State,
+			CreatedAt: r.CreatedAt.UTC(),
+		}
+	}
+	return archive
+}
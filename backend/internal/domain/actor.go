@@ -22,23 +22,57 @@ const (
 	ActorStatusBanned    = "banned"
 )
 
-// Mask represents a persona used by an actor in a specific lane
+// ValidActorStatus checks if the actor status is known
+func ValidActorStatus(status string) bool {
+	switch status {
+	case ActorStatusActive, ActorStatusSuspended, ActorStatusBanned:
+		return true
+	default:
+		return false
+	}
+}
+
+// Trust scores range from 0 to TrustScoreMax; new actors start at 1
+const TrustScoreMax = 2.0
+
+// ValidTrustScore checks if a trust score is within range
+func ValidTrustScore(score float64) bool {
+	return score >= 0 && score <= TrustScoreMax
+}
+
+// Mask represents a persona used by an actor in a specific lane. Content is
+// attributed to the mask's handle rather than the actor, and each mask is
+// replaced by a fresh one once it rotates.
 type Mask struct {
 	ID        uuid.UUID
 	ActorID   uuid.UUID
 	Lane      string
+	Handle    string
 	RotatesAt *time.Time
 	CreatedAt time.Time
 }
 
-// NewActor creates a new actor with default values
+// MaskRotationInterval is how long a mask stays in use
+// (TIMING.MASK_ROTATION_HOURS)
+const MaskRotationInterval = 24 * time.Hour
+
+// ActiveAt checks if the mask is still in use at the given time
+func (m *Mask) ActiveAt(now time.Time) bool {
+	return m.RotatesAt == nil || now.Before(*m.RotatesAt)
+}
+
+// NewActor creates a new actor with default values. The trust score starts
+// where ComputeTrust puts a brand new account, so the account age penalty
+// applies before the actor is first scored.
 func NewActor(deviceFingerprint string) *Actor {
+	id := uuid.New()
+	now := time.Now()
 	return &Actor{
-		ID:                uuid.New(),
+		ID:                id,
 		DeviceFingerprint: deviceFingerprint,
-		TrustScore:        1.0,
+		TrustScore:        ComputeTrust(id, TrustSignals{ActorCreatedAt: now}, now).Computed,
 		Status:            ActorStatusActive,
-		CreatedAt:         time.Now(),
+		CreatedAt:         now,
 	}
 }
 
@@ -47,7 +81,35 @@ func (a *Actor) IsActive() bool {
 	return a.Status == ActorStatusActive
 }
 
+// Minimum trust score required to create content
+const TrustScoreCreateMin = 0.5
+
 // CanCreate checks if the actor can create content
 func (a *Actor) CanCreate() bool {
-	return a.IsActive() && a.TrustScore >= 0.5
+	return a.IsActive() && a.TrustScore >= TrustScoreCreateMin
+}
+
+// CheckActive returns the error explaining why a non-active actor is denied
+// access, or nil if the actor is active
+func (a *Actor) CheckActive() error {
+	switch a.Status {
+	case ActorStatusActive:
+		return nil
+	case ActorStatusBanned:
+		return ErrActorBanned
+	default:
+		return ErrActorSuspended
+	}
+}
+
+// CheckCanCreate returns the error explaining why the actor may not create
+// content, or nil if they may
+func (a *Actor) CheckCanCreate() error {
+	if err := a.CheckActive(); err != nil {
+		return err
+	}
+	if !a.CanCreate() {
+		return ErrLowTrust
+	}
+	return nil
 }
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Staff is an authenticated operator of the admin API
+type Staff struct {
+	// ID identifies the staff member in the audit log, e.g. their email
+	ID     string
+	Scopes []string
+}
+
+// HasScope checks if the staff member was granted scope
+func (s *Staff) HasScope(scope string) bool {
+	for _, granted := range s.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Admin scopes
+const (
+	ScopeActorsRead   = "actors:read"
+	ScopeActorsWrite  = "actors:write"
+	ScopeForksRead    = "forks:read"
+	ScopeForksWrite   = "forks:write"
+	ScopeReportsRead  = "reports:read"
+	ScopeReportsWrite = "reports:write"
+	ScopeAuditRead    = "audit:read"
+)
+
+// ValidAdminScope checks if the admin scope is known
+func ValidAdminScope(scope string) bool {
+	switch scope {
+	case ScopeActorsRead, ScopeActorsWrite, ScopeForksRead, ScopeForksWrite,
+		ScopeReportsRead, ScopeReportsWrite, ScopeAuditRead:
+		return true
+	default:
+		return false
+	}
+}
+
+// AdminAuditEntry records one change made through the admin API. Entries
+// are append-only.
+type AdminAuditEntry struct {
+	ID         uuid.UUID
+	StaffID    string
+	Action     string
+	TargetType string
+	TargetID   uuid.UUID
+	// Details holds the action's parameters, such as the new status and the
+	// reason given
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// Audited admin actions
+const (
+	AdminActionSetActorStatus = "set_actor_status"
+	AdminActionSetTrustScore  = "set_trust_score"
+	AdminActionRemoveFork     = "remove_fork"
+	AdminActionRestoreFork    = "restore_fork"
+	AdminActionResolveReport  = "resolve_report"
+)
+
+// Audit target types
+const (
+	AuditTargetActor  = "actor"
+	AuditTargetFork   = "fork"
+	AuditTargetReport = "report"
+)
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Device auth code lifetimes. A link code is shown on a signed-in device and
+// entered on a new one to link it to the same actor.
+const (
+	DeviceChallengeTTL = 5 * time.Minute
+	DeviceLinkCodeTTL  = 10 * time.Minute
+)
+
+// Device is a device an actor signs in from, identified by a keypair the
+// device generated and holds. The fingerprint the client reports is kept
+// only as a lookup hint; possession of the private key is what proves the
+// device's identity.
+type Device struct {
+	ID      uuid.UUID
+	ActorID uuid.UUID
+	// KeyID is the hex SHA-256 of PublicKey
+	KeyID string
+	// PublicKey is a DER-encoded PKIX Ed25519 or P-256 public key
+	PublicKey   []byte
+	Fingerprint string
+	// AttestationFormat names the platform attestation the key was
+	// registered with, or is empty if it had none
+	AttestationFormat string
+	CreatedAt         time.Time
+	LastSeenAt        time.Time
+}
+
+// NewDevice creates an unregistered device for a public key. Returns a
+// FieldError for keys other than Ed25519 and P-256.
+func NewDevice(publicKey []byte, fingerprint string) (*Device, error) {
+	if _, err := parseDeviceKey(publicKey); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Device{
+		ID:          uuid.New(),
+		KeyID:       DeviceKeyID(publicKey),
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+	}, nil
+}
+
+// DeviceKeyID derives the ID a device public key is looked up by
+func DeviceKeyID(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySignature checks that the device's private key signed message.
+// Ed25519 signatures are raw. P-256 signatures are over the message's
+// SHA-256, either ASN.1 DER as produced by mobile platform keystores or the
+// 64-byte r||s form produced by WebCrypto.
+func (d *Device) VerifySignature(message, signature []byte) error {
+	key, err := parseDeviceKey(d.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, message, signature)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		valid = ecdsa.VerifyASN1(k, digest[:], signature)
+		if !valid && len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			valid = ecdsa.Verify(k, digest[:], r, s)
+		}
+	}
+	if !valid {
+		return ErrInvalidDeviceSignature
+	}
+	return nil
+}
+
+func parseDeviceKey(publicKey []byte) (any, error) {
+	errKey := &FieldError{Field: "public_key", Err: ErrInvalidInput}
+
+	key, err := x509.ParsePKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, errKey
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return k, nil
+		}
+	}
+	return nil, errKey
+}
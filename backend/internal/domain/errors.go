@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrNotFound        = errors.New("not found")
@@ -10,4 +13,56 @@ var (
 	ErrInvalidInput    = errors.New("invalid input")
 	ErrUnauthorized    = errors.New("unauthorized")
 	ErrRateLimited     = errors.New("rate limited")
+	ErrActorSuspended  = errors.New("actor is suspended")
+	ErrActorBanned     = errors.New("actor is banned")
+	ErrLowTrust        = errors.New("trust score too low to create content")
+
+	ErrParentUnavailable = errors.New("parent fork does not exist or cannot be twisted")
+	ErrInvalidTransition = errors.New("invalid state transition")
+	ErrDuplicateReport   = errors.New("fork already has an open report from this actor")
+
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; session revoked")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+
+	ErrInvalidChallenge       = errors.New("challenge is invalid, expired or already used")
+	ErrInvalidDeviceSignature = errors.New("device signature is invalid")
+	ErrAttestationRequired    = errors.New("device attestation is required")
+	ErrInvalidAttestation     = errors.New("device attestation is invalid")
+
+	ErrInvalidLinkCode         = errors.New("link code is invalid, expired or already used")
+	ErrInvalidRecoveryCode     = errors.New("recovery code is invalid")
+	ErrDeviceAlreadyRegistered = errors.New("device key is already registered")
+	ErrLastDevice              = errors.New("cannot unlink the only linked device")
+	ErrAlreadyClaimed          = errors.New("account already has a registered device")
 )
+
+// RateLimitError is returned when a quota is exhausted. It matches
+// ErrRateLimited with errors.Is and carries how long until a retry can
+// succeed.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return ErrRateLimited.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// FieldError attributes a validation error to a specific input field. It
+// matches the wrapped sentinel with errors.Is.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -21,13 +21,18 @@ type Fork struct {
 	CognitiveLoad    string
 	ParentForkID     *uuid.UUID
 	MutationType     string
+	MutationDiff     *MutationDiff
 	SafetyAgeGate    string
 	SafetySensitivity string
 	SafetyFlags      []string
+	Status           string
 	CreatedByActorID uuid.UUID
 	CreatedByMaskID  *uuid.UUID
 	CreatedAt        time.Time
 
+	// Handle of the creator's mask, if any (computed)
+	MaskHandle string
+
 	// Aggregated stats (computed)
 	LeftCount  int
 	RightCount int
@@ -37,6 +42,27 @@ type Fork struct {
 	ReportRate float64
 }
 
+// Fork statuses. Only visible forks are served to clients; hidden forks are
+// awaiting moderation and removed forks have been taken down.
+const (
+	ForkStatusVisible = "visible"
+	ForkStatusHidden  = "hidden"
+	ForkStatusRemoved = "removed"
+)
+
+// AgeGateAll marks a fork as suitable for every audience
+const AgeGateAll = "all"
+
+// CheckTwistable returns ErrParentUnavailable if the fork can't be used as a
+// twist parent. Forks that aren't visible are treated as missing, and
+// age-gated forks are excluded because twists are published ungated.
+func (f *Fork) CheckTwistable() error {
+	if f.Status != ForkStatusVisible || f.SafetyAgeGate != AgeGateAll {
+		return ErrParentUnavailable
+	}
+	return nil
+}
+
 // CreateForkInput represents the input for creating a new fork
 type CreateForkInput struct {
 	Prompt       string
@@ -52,16 +78,25 @@ type CreateForkInput struct {
 // Validate validates the fork input
 func (f *CreateForkInput) Validate() error {
 	if len(f.Prompt) > 90 {
-		return ErrPromptTooLong
+		return &FieldError{Field: "prompt", Err: ErrPromptTooLong}
 	}
 	if len(f.LeftLabel) > 24 {
-		return ErrLabelTooLong
+		return &FieldError{Field: "left_label", Err: ErrLabelTooLong}
 	}
 	if len(f.RightLabel) > 24 {
-		return ErrLabelTooLong
+		return &FieldError{Field: "right_label", Err: ErrLabelTooLong}
+	}
+	if f.Prompt == "" {
+		return &FieldError{Field: "prompt", Err: ErrMissingRequired}
+	}
+	if f.LeftLabel == "" {
+		return &FieldError{Field: "left_label", Err: ErrMissingRequired}
+	}
+	if f.RightLabel == "" {
+		return &FieldError{Field: "right_label", Err: ErrMissingRequired}
 	}
-	if f.Prompt == "" || f.LeftLabel == "" || f.RightLabel == "" {
-		return ErrMissingRequired
+	if f.IntentLane == "" {
+		return &FieldError{Field: "intent_lane", Err: ErrMissingRequired}
 	}
 	return nil
 }
@@ -89,18 +124,44 @@ const (
 	InteractionSwipeRight = "swipe_right"
 	InteractionSkip       = "skip"
 	InteractionTwist      = "twist"
+
+	// InteractionRetract is recorded in the history when an actor withdraws
+	// their vote; clients cannot submit it directly.
+	InteractionRetract = "retract"
 )
 
-// ValidInteractionType checks if the interaction type is valid
+// ValidInteractionType checks if clients may submit the interaction type.
+// Twists are recorded when the twist fork is created, and retractions when
+// a vote is withdrawn.
 func ValidInteractionType(t string) bool {
 	switch t {
-	case InteractionSwipeLeft, InteractionSwipeRight, InteractionSkip, InteractionTwist:
+	case InteractionSwipeLeft, InteractionSwipeRight, InteractionSkip:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsVoteType checks if the interaction type counts as a vote. An actor has at
+// most one vote per fork; casting another replaces it.
+func IsVoteType(t string) bool {
+	switch t {
+	case InteractionSwipeLeft, InteractionSwipeRight, InteractionSkip:
 		return true
 	default:
 		return false
 	}
 }
 
+// Vote represents an actor's current vote on a fork
+type Vote struct {
+	ActorID   uuid.UUID
+	ForkID    uuid.UUID
+	Type      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 // Report represents a content report
 type Report struct {
 	ID        uuid.UUID
@@ -128,6 +189,16 @@ const (
 	ReportReasonOther         = "other"
 )
 
+// ValidReportReason checks if the report reason is valid
+func ValidReportReason(reason string) bool {
+	switch reason {
+	case ReportReasonInappropriate, ReportReasonSpam, ReportReasonHarassment, ReportReasonHateSpeech, ReportReasonOther:
+		return true
+	default:
+		return false
+	}
+}
+
 // Mutation types
 const (
 	MutationFlip      = "flip"       // Swap left/right labels
@@ -0,0 +1,100 @@
+package domain
+
+// IntentOption is one choice in the intent catalog
+type IntentOption struct {
+	ID          string
+	Label       string
+	Description string
+	Emoji       string
+}
+
+// The intent catalog: the lanes, energies and moods an actor can choose for
+// their feed session
+var (
+	IntentLanes = []IntentOption{
+		{ID: "discover", Label: "Discover", Description: "Explore new ideas and perspectives", Emoji: "🔍"},
+		{ID: "debate", Label: "Debate", Description: "Engage in friendly arguments", Emoji: "⚔️"},
+		{ID: "vibe", Label: "Vibe", Description: "Light-hearted fun and entertainment", Emoji: "✨"},
+		{ID: "reflect", Label: "Reflect", Description: "Deep thoughts and introspection", Emoji: "🪞"},
+		{ID: "decide", Label: "Decide", Description: "Help making real choices", Emoji: "🎯"},
+	}
+	IntentEnergies = []IntentOption{
+		{ID: "chill", Label: "Chill", Description: "Relaxed, low-stakes choices", Emoji: "😌"},
+		{ID: "balanced", Label: "Balanced", Description: "Mix of easy and engaging", Emoji: "⚖️"},
+		{ID: "intense", Label: "Intense", Description: "High-stakes, thought-provoking", Emoji: "🔥"},
+	}
+	IntentMoods = []IntentOption{
+		{ID: "playful", Label: "Playful", Emoji: "😄"},
+		{ID: "serious", Label: "Serious", Emoji: "🤔"},
+		{ID: "spicy", Label: "Spicy", Emoji: "🌶️"},
+		{ID: "wholesome", Label: "Wholesome", Emoji: "💖"},
+		{ID: "chaotic", Label: "Chaotic", Emoji: "🌪️"},
+	}
+)
+
+// Intent is what an actor is in the mood for. Empty fields match anything.
+type Intent struct {
+	Lane   string
+	Energy string
+	Mood   string
+}
+
+// IntentSwitch is an entry of an actor's session history: the intent they
+// switched to and when
+type IntentSwitch struct {
+	Lane   string `json:"lane,omitempty"`
+	Energy string `json:"energy,omitempty"`
+	Mood   string `json:"mood,omitempty"`
+	At     int64  `json:"at"` // unix seconds
+}
+
+// Validate checks every chosen field against the intent catalog
+func (i Intent) Validate() error {
+	if i.Lane != "" && !inCatalog(IntentLanes, i.Lane) {
+		return &FieldError{Field: "lane", Err: ErrInvalidInput}
+	}
+	if i.Energy != "" && !inCatalog(IntentEnergies, i.Energy) {
+		return &FieldError{Field: "energy", Err: ErrInvalidInput}
+	}
+	if i.Mood != "" && !inCatalog(IntentMoods, i.Mood) {
+		return &FieldError{Field: "mood", Err: ErrInvalidInput}
+	}
+	return nil
+}
+
+// Known returns i with the fields that aren't in the intent catalog cleared
+func (i Intent) Known() Intent {
+	if !inCatalog(IntentLanes, i.Lane) {
+		i.Lane = ""
+	}
+	if !inCatalog(IntentEnergies, i.Energy) {
+		i.Energy = ""
+	}
+	if !inCatalog(IntentMoods, i.Mood) {
+		i.Mood = ""
+	}
+	return i
+}
+
+// Merge fills the fields i leaves empty from fallback
+func (i Intent) Merge(fallback Intent) Intent {
+	if i.Lane == "" {
+		i.Lane = fallback.Lane
+	}
+	if i.Energy == "" {
+		i.Energy = fallback.Energy
+	}
+	if i.Mood == "" {
+		i.Mood = fallback.Mood
+	}
+	return i
+}
+
+func inCatalog(options []IntentOption, id string) bool {
+	for _, option := range options {
+		if option.ID == id {
+			return true
+		}
+	}
+	return false
+}
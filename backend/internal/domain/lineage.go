@@ -0,0 +1,40 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ForkNode is a fork and the twists that branched from it
+type ForkNode struct {
+	Fork     *Fork
+	Children []*ForkNode
+}
+
+// Lineage describes how a fork descends from its origin and what has
+// branched from it since
+type Lineage struct {
+	// Ancestors runs from the root fork down to the fork's direct parent
+	Ancestors []*Fork
+	// Tree is rooted at the fork itself
+	Tree *ForkNode
+	// Truncated is set when descendants were cut off by the node limit
+	Truncated bool
+}
+
+// BuildForkTree arranges descendants (in any order) under root by their
+// ParentForkID. Forks whose parent isn't present are dropped.
+func BuildForkTree(root *Fork, descendants []*Fork) *ForkNode {
+	rootNode := &ForkNode{Fork: root}
+	nodes := map[uuid.UUID]*ForkNode{root.ID: rootNode}
+	for _, f := range descendants {
+		nodes[f.ID] = &ForkNode{Fork: f}
+	}
+
+	for _, f := range descendants {
+		if f.ParentForkID == nil {
+			continue
+		}
+		if parent, ok := nodes[*f.ParentForkID]; ok {
+			parent.Children = append(parent.Children, nodes[f.ID])
+		}
+	}
+	return rootNode
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AutoHideReportWeight is the trust-weighted total of open reports at which a
+// fork is hidden pending review (SAFETY.AUTO_HIDE_REPORT_COUNT)
+const AutoHideReportWeight = 3.0
+
+// ReportWeight is how much a report counts toward auto-hiding given the
+// reporter's trust score. A fully trusted reporter counts once; less trusted
+// reporters count proportionally less.
+func ReportWeight(trustScore float64) float64 {
+	if trustScore < 0 {
+		return 0
+	}
+	if trustScore > 1 {
+		return 1
+	}
+	return trustScore
+}
+
+// ValidReportState checks if the report state is known
+func ValidReportState(state string) bool {
+	switch state {
+	case ReportStatePending, ReportStateReviewed, ReportStateDismissed, ReportStateActioned:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsOpenReportState checks if a report in this state still awaits a decision
+func IsOpenReportState(state string) bool {
+	return state == ReportStatePending || state == ReportStateReviewed
+}
+
+// ValidReportTransition checks if a report may move between states. Pending
+// reports can be marked reviewed; open reports can be dismissed or actioned,
+// which is final.
+func ValidReportTransition(from, to string) bool {
+	switch to {
+	case ReportStateReviewed:
+		return from == ReportStatePending
+	case ReportStateDismissed, ReportStateActioned:
+		return IsOpenReportState(from)
+	default:
+		return false
+	}
+}
+
+// ValidForkTransition checks if a fork may move between statuses
+func ValidForkTransition(from, to string) bool {
+	switch to {
+	case ForkStatusHidden:
+		return from == ForkStatusVisible
+	case ForkStatusRemoved:
+		return from == ForkStatusVisible || from == ForkStatusHidden
+	case ForkStatusVisible:
+		return from == ForkStatusHidden || from == ForkStatusRemoved
+	default:
+		return false
+	}
+}
+
+// Reporter abuse thresholds. Once enough of an actor's reports have been
+// decided, a high dismissed ratio caps their trust score, which in turn
+// shrinks the weight of their future reports.
+const (
+	ReporterMinDecided        = 5
+	ReporterDismissedRatioMax = 0.5
+)
+
+// ReporterStats counts how an actor's decided reports were resolved
+type ReporterStats struct {
+	Dismissed int
+	Actioned  int
+}
+
+// DismissedRatio is the share of decided reports that were dismissed
+func (s ReporterStats) DismissedRatio() float64 {
+	decided := s.Dismissed + s.Actioned
+	if decided == 0 {
+		return 0
+	}
+	return float64(s.Dismissed) / float64(decided)
+}
+
+// TrustCap returns the highest trust score a reporter with these stats may
+// keep, and false if their record doesn't warrant a cap
+func (s ReporterStats) TrustCap() (float64, bool) {
+	if s.Dismissed+s.Actioned < ReporterMinDecided {
+		return 0, false
+	}
+	ratio := s.DismissedRatio()
+	if ratio <= ReporterDismissedRatioMax {
+		return 0, false
+	}
+	return 1 - ratio, true
+}
+
+// ModerationAction is an entry in the moderation audit log
+type ModerationAction struct {
+	ID uuid.UUID
+	// Moderator is the staff ID of the moderator, empty for actions taken
+	// automatically
+	Moderator string
+	Action    string
+	ForkID    uuid.UUID
+	ReportID  *uuid.UUID
+	Note      string
+	CreatedAt time.Time
+}
+
+// Moderation actions
+const (
+	ModerationAutoHide      = "auto_hide"
+	ModerationRestoreFork   = "restore_fork"
+	ModerationRemoveFork    = "remove_fork"
+	ModerationReviewReport  = "review_report"
+	ModerationDismissReport = "dismiss_report"
+	ModerationActionReport  = "action_report"
+)
+
+// ReportTransitionAction names the audit action for moving a report to state
+func ReportTransitionAction(state string) string {
+	switch state {
+	case ReportStateReviewed:
+		return ModerationReviewReport
+	case ReportStateDismissed:
+		return ModerationDismissReport
+	default:
+		return ModerationActionReport
+	}
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "errors"
+
+var (
+	ErrMutationRequiresParent = errors.New("mutation type requires a parent fork")
+	ErrInvalidMutation        = errors.New("twist does not match its mutation type")
+	ErrUnknownMutation        = errors.New("unknown mutation type")
+)
+
+// TextChange records a field's value in the parent and in the twist
+type TextChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MutationDiff is the structured difference between a twist and its parent.
+// Only fields that changed are set. It is persisted as JSON on the fork.
+type MutationDiff struct {
+	Prompt        *TextChange `json:"prompt,omitempty"`
+	LeftLabel     *TextChange `json:"left_label,omitempty"`
+	RightLabel    *TextChange `json:"right_label,omitempty"`
+	IntentLane    *TextChange `json:"intent_lane,omitempty"`
+	Mood          *TextChange `json:"mood,omitempty"`
+	Energy        *TextChange `json:"energy,omitempty"`
+	LabelsSwapped bool        `json:"labels_swapped,omitempty"`
+}
+
+// IsEmpty reports whether the twist is identical to its parent
+func (d *MutationDiff) IsEmpty() bool {
+	return d.Prompt == nil && d.LeftLabel == nil && d.RightLabel == nil &&
+		d.IntentLane == nil && d.Mood == nil && d.Energy == nil
+}
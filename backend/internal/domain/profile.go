@@ -0,0 +1,29 @@
+package domain
+
+// ActorStats summarizes an actor's activity for their profile
+type ActorStats struct {
+	// Votes counts the actor's current votes, skips included
+	Votes         int
+	ForksCreated  int
+	TwistsCreated int
+	// ContestedVotes counts the actor's left or right votes on forks where
+	// everyone else's votes aren't tied, and MajorityVotes those of them on
+	// the side most others took
+	ContestedVotes int
+	MajorityVotes  int
+}
+
+// MajorityRate is the share of contested votes that sided with the
+// majority, or 0 if there are none
+func (s ActorStats) MajorityRate() float64 {
+	if s.ContestedVotes == 0 {
+		return 0
+	}
+	return float64(s.MajorityVotes) / float64(s.ContestedVotes)
+}
+
+// VotedFork is one of an actor's votes with the fork it was cast on
+type VotedFork struct {
+	Vote *Vote
+	Fork *Fork
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token lifetimes. Access tokens are short-lived JWTs; refresh tokens are
+// opaque, stored server-side and replaced on every use.
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Session is one sign-in of an actor on a device. Every access and refresh
+// token belongs to a session, and revoking it invalidates them all.
+type Session struct {
+	ID      uuid.UUID
+	ActorID uuid.UUID
+	// DeviceID is the device the session was started from. It is nil for
+	// sessions started before devices were registered.
+	DeviceID  *uuid.UUID
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// RefreshToken is the server-side record of a refresh token. Only a hash of
+// the token is stored.
+type RefreshToken struct {
+	Hash      string
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Trust scoring windows. Reports count toward an author's score for
+// TrustReportWindow; swipe velocity is measured over TrustVelocityWindow.
+const (
+	TrustReportWindow   = 90 * 24 * time.Hour
+	TrustVelocityWindow = 10 * time.Minute
+)
+
+// Trust scoring weights. A score starts at TrustScoreBase and each signal
+// adds or subtracts a bounded amount.
+const (
+	TrustScoreBase = 1.0
+
+	// A brand new account starts TrustAccountAgeMax below the base and
+	// gains trust linearly until it is TrustAccountAgeMax above it
+	TrustAccountAgeMax  = 0.2
+	TrustAccountAgeDays = 180
+
+	// Each open or upheld report against the actor's forks
+	TrustReportReceivedPenalty = 0.05
+	TrustReportReceivedMax     = 0.3
+
+	// Each report against the actor's forks that moderators upheld, on top
+	// of it counting as received
+	TrustReportUpheldPenalty = 0.2
+	TrustReportUpheldMax     = 0.8
+
+	// Each report the actor filed that moderators upheld
+	TrustReportFiledBonus = 0.05
+	TrustReportFiledMax   = 0.2
+
+	// The skip rate of the actor's forks is only judged once they have
+	// TrustSkipMinImpressions votes. A rate of TrustSkipRateNeutral is
+	// neutral; each point above or below moves the score by
+	// TrustSkipRateWeight.
+	TrustSkipMinImpressions = 50
+	TrustSkipRateNeutral    = 0.2
+	TrustSkipRateWeight     = 0.5
+
+	// Swiping faster than TrustSwipesPerMinuteMax over the velocity window
+	// looks automated. The penalty grows linearly to TrustVelocityPenaltyMax
+	// at twice that rate.
+	TrustSwipesPerMinuteMax = 30
+	TrustVelocityPenaltyMax = 0.5
+)
+
+// Trust signals, as named in a score's contributions
+const (
+	TrustSignalAccountAge      = "account_age"
+	TrustSignalReportsReceived = "reports_received"
+	TrustSignalReportsUpheld   = "reports_upheld"
+	TrustSignalReportsFiled    = "reports_filed"
+	TrustSignalReporterCap     = "reporter_cap"
+	TrustSignalSkipRate        = "skip_rate"
+	TrustSignalSwipeVelocity   = "swipe_velocity"
+)
+
+// TrustSignals are the behavioural inputs to an actor's trust score
+type TrustSignals struct {
+	ActorCreatedAt time.Time
+	// ReportsReceived counts reports filed within the report window against
+	// forks the actor created, other than dismissed ones
+	ReportsReceived int
+	// ReportsUpheld counts those of them that moderators actioned
+	ReportsUpheld int
+	// Reporter counts how the reports the actor filed were resolved
+	Reporter ReporterStats
+	// Impressions and Skips count the current votes on the actor's forks
+	Impressions int
+	Skips       int
+	// RecentSwipes counts the actor's swipes and skips within the velocity
+	// window
+	RecentSwipes int
+}
+
+// TrustContribution is one signal's share of a trust score. Value is the
+// raw signal (days, a count, a rate) and Delta what it added to the score.
+type TrustContribution struct {
+	Signal string  `json:"signal"`
+	Value  float64 `json:"value"`
+	Delta  float64 `json:"delta"`
+}
+
+// TrustScore explains an actor's trust score. Computed is TrustScoreBase
+// plus the contributions, kept within 0..TrustScoreMax; a staff Override
+// takes precedence over it.
+type TrustScore struct {
+	ActorID       uuid.UUID
+	Computed      float64
+	Contributions []TrustContribution
+	// ComputedAt is zero if the actor has only been given an override
+	ComputedAt time.Time
+	Override   *float64
+}
+
+// Effective is the score the actor is held to
+func (t *TrustScore) Effective() float64 {
+	if t.Override != nil {
+		return *t.Override
+	}
+	return t.Computed
+}
+
+// ComputeTrust scores an actor from their signals as of now
+func ComputeTrust(actorID uuid.UUID, signals TrustSignals, now time.Time) *TrustScore {
+	var contributions []TrustContribution
+	add := func(signal string, value, delta float64) {
+		contributions = append(contributions, TrustContribution{Signal: signal, Value: value, Delta: delta})
+	}
+
+	days := now.Sub(signals.ActorCreatedAt).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	add(TrustSignalAccountAge, days, TrustAccountAgeMax*(2*math.Min(days/TrustAccountAgeDays, 1)-1))
+
+	if signals.ReportsReceived > 0 {
+		add(TrustSignalReportsReceived, float64(signals.ReportsReceived),
+			-math.Min(float64(signals.ReportsReceived)*TrustReportReceivedPenalty, TrustReportReceivedMax))
+	}
+	if signals.ReportsUpheld > 0 {
+		add(TrustSignalReportsUpheld, float64(signals.ReportsUpheld),
+			-math.Min(float64(signals.ReportsUpheld)*TrustReportUpheldPenalty, TrustReportUpheldMax))
+	}
+	if signals.Reporter.Actioned > 0 {
+		add(TrustSignalReportsFiled, float64(signals.Reporter.Actioned),
+			math.Min(float64(signals.Reporter.Actioned)*TrustReportFiledBonus, TrustReportFiledMax))
+	}
+
+	if signals.Impressions >= TrustSkipMinImpressions {
+		rate := float64(signals.Skips) / float64(signals.Impressions)
+		add(TrustSignalSkipRate, rate, (TrustSkipRateNeutral-rate)*TrustSkipRateWeight)
+	}
+
+	perMinute := float64(signals.RecentSwipes) / TrustVelocityWindow.Minutes()
+	if perMinute > TrustSwipesPerMinuteMax {
+		excess := math.Min((perMinute-TrustSwipesPerMinuteMax)/TrustSwipesPerMinuteMax, 1)
+		add(TrustSignalSwipeVelocity, perMinute, -excess*TrustVelocityPenaltyMax)
+	}
+
+	score := TrustScoreBase
+	for _, c := range contributions {
+		score += c.Delta
+	}
+	score = math.Max(0, math.Min(score, TrustScoreMax))
+
+	// Reporters whose reports keep being dismissed are capped regardless of
+	// their other signals
+	if trustCap, ok := signals.Reporter.TrustCap(); ok && score > trustCap {
+		add(TrustSignalReporterCap, signals.Reporter.DismissedRatio(), trustCap-score)
+		score = trustCap
+	}
+
+	return &TrustScore{
+		ActorID:       actorID,
+		Computed:      score,
+		Contributions: contributions,
+		ComputedAt:    now,
+	}
+}
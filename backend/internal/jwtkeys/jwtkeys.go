@@ -0,0 +1,316 @@
+// Package jwtkeys manages the keys actor access tokens are signed and
+// verified with. Every key has an ID that is stamped into the kid header of
+// the tokens it signs, so several keys can be valid at once and a key can be
+// rotated out without invalidating the tokens already issued with it.
+//
+// Each key has an optional window [NotBefore, NotAfter) during which it
+// signs. When windows overlap the most recently activated key signs, which
+// lets a new key be staged ahead of time: it is published in the JWKS from
+// startup, takes over signing when its window opens, and the key it
+// replaces keeps verifying until the tokens it signed have expired.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/forkfall/backend/internal/clock"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey = errors.New("no JWT signing key is active")
+	ErrUnknownKey   = errors.New("unknown JWT key")
+)
+
+// Key is a single signing or verification key
+type Key struct {
+	// ID is the key's kid
+	ID string
+	// NotBefore and NotAfter bound when the key signs; zero values leave the
+	// window open on that side
+	NotBefore time.Time
+	NotAfter  time.Time
+
+	method jwt.SigningMethod
+	// signKey is nil for keys that only verify
+	signKey   crypto.PrivateKey
+	verifyKey crypto.PublicKey
+	// public is false for HMAC secrets, which are never published
+	public bool
+}
+
+// Algorithm returns the JWS algorithm of the key, e.g. EdDSA
+func (k *Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+func (k *Key) signsAt(now time.Time) bool {
+	return k.signKey != nil &&
+		!now.Before(k.NotBefore) &&
+		(k.NotAfter.IsZero() || now.Before(k.NotAfter))
+}
+
+// verifiesAt reports whether tokens signed with the key are still accepted.
+// A retired key verifies for grace after its window closes, long enough for
+// the last tokens it signed to expire.
+func (k *Key) verifiesAt(now time.Time, grace time.Duration) bool {
+	return k.NotAfter.IsZero() || now.Before(k.NotAfter.Add(grace))
+}
+
+// NewHMACKey creates an HS256 key from a shared secret. It is meant for
+// development; HMAC keys are not published in the JWKS.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{
+		ID:        id,
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// ParseKey creates a key from a PEM-encoded Ed25519 or P-256 private key,
+// which signs as EdDSA or ES256 respectively, or from a public key, which
+// only verifies
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block found", id)
+	}
+
+	var (
+		parsed any
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	key := &Key{ID: id, public: true}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.method, key.signKey, key.verifyKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.verifyKey = jwt.SigningMethodEdDSA, k
+	case *ecdsa.PrivateKey:
+		key.method, key.signKey, key.verifyKey = jwt.SigningMethodES256, k, &k.PublicKey
+	case *ecdsa.PublicKey:
+		key.method, key.verifyKey = jwt.SigningMethodES256, k
+	default:
+		return nil, fmt.Errorf("key %s: only Ed25519 and P-256 keys are supported", id)
+	}
+	if pub, ok := key.verifyKey.(*ecdsa.PublicKey); ok && pub.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("key %s: ES256 requires a P-256 key", id)
+	}
+	return key, nil
+}
+
+// manifest is the key file format read by LoadFile
+type manifest struct {
+	Keys []struct {
+		ID        string     `json:"kid"`
+		File      string     `json:"file"`
+		NotBefore *time.Time `json:"not_before"`
+		NotAfter  *time.Time `json:"not_after"`
+	} `json:"keys"`
+}
+
+// LoadFile reads the keys listed in a JSON manifest such as
+//
+//	{"keys": [
+//	  {"kid": "2026-10", "file": "2026-10.pem", "not_before": "2026-10-01T00:00:00Z"},
+//	  {"kid": "2026-07", "file": "2026-07.pem", "not_after": "2026-10-01T00:00:00Z"}
+//	]}
+//
+// Key files are resolved relative to the manifest's directory.
+func LoadFile(path string) ([]*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	keys := make([]*Key, 0, len(m.Keys))
+	for _, entry := range m.Keys {
+		if entry.ID == "" || entry.File == "" {
+			return nil, fmt.Errorf("%s: every key needs a kid and a file", path)
+		}
+		file := entry.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		pemData, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", entry.ID, err)
+		}
+		key, err := ParseKey(entry.ID, pemData)
+		if err != nil {
+			return nil, err
+		}
+		if entry.NotBefore != nil {
+			key.NotBefore = *entry.NotBefore
+		}
+		if entry.NotAfter != nil {
+			key.NotAfter = *entry.NotAfter
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Manager signs tokens with the currently active key and verifies them
+// against any key still in rotation
+type Manager struct {
+	keys  []*Key
+	grace time.Duration
+	clock clock.Clock
+}
+
+// NewManager creates a manager over keys. grace is the longest lifetime of
+// a token signed with them. Fails if key IDs repeat, if a key's window is
+// empty, or if no key can sign right now.
+func NewManager(keys []*Key, grace time.Duration, clk clock.Clock) (*Manager, error) {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate JWT key ID %q", key.ID)
+		}
+		seen[key.ID] = true
+		if !key.NotAfter.IsZero() && !key.NotBefore.Before(key.NotAfter) {
+			return nil, fmt.Errorf("JWT key %s: not_before must be before not_after", key.ID)
+		}
+	}
+
+	m := &Manager{keys: keys, grace: grace, clock: clk}
+	if _, err := m.signingKey(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// signingKey returns the most recently activated key that can sign now
+func (m *Manager) signingKey() (*Key, error) {
+	now := m.clock.Now()
+	var current *Key
+	for _, key := range m.keys {
+		if key.signsAt(now) && (current == nil || key.NotBefore.After(current.NotBefore)) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return current, nil
+}
+
+// Sign signs claims with the active key and stamps its kid into the header
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	key, err := m.signingKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc resolves the verification key named by a token's kid header. It
+// rejects tokens without a kid, signed with a key that has been retired, or
+// whose algorithm doesn't match their key.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	now := m.clock.Now()
+	for _, key := range m.keys {
+		if key.ID != kid {
+			continue
+		}
+		if !key.verifiesAt(now, m.grace) || token.Method.Alg() != key.Algorithm() {
+			return nil, ErrUnknownKey
+		}
+		return key.verifyKey, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// Algorithms returns the JWS algorithms of all keys, for restricting which
+// algorithms the parser accepts
+func (m *Manager) Algorithms() []string {
+	var algs []string
+	seen := make(map[string]bool)
+	for _, key := range m.keys {
+		if alg := key.Algorithm(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWK is a public key in JSON Web Key form
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKSet is the document served at the JWKS endpoint
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys tokens may currently be verified with,
+// including keys staged to sign in the future
+func (m *Manager) JWKS() JWKSet {
+	now := m.clock.Now()
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if !key.public || !key.verifiesAt(now, m.grace) {
+			continue
+		}
+		jwk := JWK{ID: key.ID, Use: "sig", Algorithm: key.Algorithm()}
+		switch pub := key.verifyKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType, jwk.Curve = "OKP", "Ed25519"
+			jwk.X = encodeSegment(pub)
+		case *ecdsa.PublicKey:
+			jwk.KeyType, jwk.Curve = "EC", "P-256"
+			// Coordinates are fixed-width, left-padded to the curve size
+			jwk.X = encodeSegment(pub.X.FillBytes(make([]byte, 32)))
+			jwk.Y = encodeSegment(pub.Y.FillBytes(make([]byte, 32)))
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,165 @@
+// Package mutation checks that a twist actually performs the mutation it
+// claims against its parent fork, generates mechanical mutations, and
+// describes how a twist differs from its parent.
+package mutation
+
+import (
+	"strings"
+
+	"github.com/forkfall/backend/internal/domain"
+)
+
+// Rule validates one mutation type
+type Rule interface {
+	// Validate returns domain.ErrInvalidMutation (possibly wrapped) if child
+	// is not a valid application of the mutation to parent
+	Validate(parent *domain.Fork, child *domain.CreateForkInput, diff *domain.MutationDiff) error
+}
+
+// Generator is a Rule that can derive the twist from the parent alone
+type Generator interface {
+	Rule
+	Generate(parent *domain.Fork) domain.CreateForkInput
+}
+
+// Engine dispatches to the rule registered for each mutation type
+type Engine struct {
+	rules map[string]Rule
+}
+
+// NewEngine creates an engine with the built-in rules for every
+// domain mutation type
+func NewEngine() *Engine {
+	return &Engine{
+		rules: map[string]Rule{
+			domain.MutationFlip:     flipRule{},
+			domain.MutationReframe:  reframeRule{},
+			domain.MutationEscalate: changedPromptRule{},
+			domain.MutationSpecific: changedPromptRule{},
+			domain.MutationOpposite: oppositeRule{},
+		},
+	}
+}
+
+// Inherit fills fields the twist left empty from its parent
+func (e *Engine) Inherit(parent *domain.Fork, child *domain.CreateForkInput) {
+	if child.IntentLane == "" {
+		child.IntentLane = parent.IntentLane
+	}
+	if child.Mood == "" {
+		child.Mood = parent.Mood
+	}
+	if child.Energy == "" {
+		child.Energy = parent.Energy
+	}
+}
+
+// Validate checks child against parent for the child's mutation type and
+// returns the diff to record on success
+func (e *Engine) Validate(parent *domain.Fork, child *domain.CreateForkInput) (*domain.MutationDiff, error) {
+	rule, ok := e.rules[child.MutationType]
+	if !ok {
+		return nil, &domain.FieldError{Field: "mutation_type", Err: domain.ErrUnknownMutation}
+	}
+
+	diff := Diff(parent, child)
+	if diff.IsEmpty() {
+		return nil, &domain.FieldError{Field: "mutation_type", Err: domain.ErrInvalidMutation}
+	}
+	if err := rule.Validate(parent, child, diff); err != nil {
+		return nil, &domain.FieldError{Field: "mutation_type", Err: err}
+	}
+	return diff, nil
+}
+
+// Generate derives a twist mechanically. Only mutation types whose rule is a
+// Generator are supported; others return domain.ErrUnknownMutation.
+func (e *Engine) Generate(mutationType string, parent *domain.Fork) (domain.CreateForkInput, error) {
+	gen, ok := e.rules[mutationType].(Generator)
+	if !ok {
+		return domain.CreateForkInput{}, &domain.FieldError{Field: "mutation_type", Err: domain.ErrUnknownMutation}
+	}
+	child := gen.Generate(parent)
+	child.MutationType = mutationType
+	parentID := parent.ID
+	child.ParentForkID = &parentID
+	return child, nil
+}
+
+// Diff describes how child differs from parent
+func Diff(parent *domain.Fork, child *domain.CreateForkInput) *domain.MutationDiff {
+	return &domain.MutationDiff{
+		Prompt:        change(parent.Prompt, child.Prompt),
+		LeftLabel:     change(parent.LeftLabel, child.LeftLabel),
+		RightLabel:    change(parent.RightLabel, child.RightLabel),
+		IntentLane:    change(parent.IntentLane, child.IntentLane),
+		Mood:          change(parent.Mood, child.Mood),
+		Energy:        change(parent.Energy, child.Energy),
+		LabelsSwapped: same(parent.LeftLabel, child.RightLabel) && same(parent.RightLabel, child.LeftLabel),
+	}
+}
+
+func change(from, to string) *domain.TextChange {
+	if same(from, to) {
+		return nil
+	}
+	return &domain.TextChange{From: from, To: to}
+}
+
+// same compares text ignoring case and surrounding whitespace
+func same(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// flipRule: the labels trade places; the prompt may be reworded
+type flipRule struct{}
+
+func (flipRule) Validate(parent *domain.Fork, child *domain.CreateForkInput, diff *domain.MutationDiff) error {
+	if !diff.LabelsSwapped {
+		return domain.ErrInvalidMutation
+	}
+	return nil
+}
+
+func (flipRule) Generate(parent *domain.Fork) domain.CreateForkInput {
+	return domain.CreateForkInput{
+		Prompt:     parent.Prompt,
+		LeftLabel:  parent.RightLabel,
+		RightLabel: parent.LeftLabel,
+		IntentLane: parent.IntentLane,
+		Mood:       parent.Mood,
+		Energy:     parent.Energy,
+	}
+}
+
+// reframeRule: same labels in the same order, new prompt
+type reframeRule struct{}
+
+func (reframeRule) Validate(parent *domain.Fork, child *domain.CreateForkInput, diff *domain.MutationDiff) error {
+	if diff.LeftLabel != nil || diff.RightLabel != nil || diff.Prompt == nil {
+		return domain.ErrInvalidMutation
+	}
+	return nil
+}
+
+// changedPromptRule covers escalate and specific: the prompt must be
+// rewritten; labels may change along with it
+type changedPromptRule struct{}
+
+func (changedPromptRule) Validate(parent *domain.Fork, child *domain.CreateForkInput, diff *domain.MutationDiff) error {
+	if diff.Prompt == nil {
+		return domain.ErrInvalidMutation
+	}
+	return nil
+}
+
+// oppositeRule: a different scenario, so the prompt or labels must change
+// in a way that isn't merely a flip
+type oppositeRule struct{}
+
+func (oppositeRule) Validate(parent *domain.Fork, child *domain.CreateForkInput, diff *domain.MutationDiff) error {
+	if diff.Prompt == nil && (diff.LabelsSwapped || (diff.LeftLabel == nil && diff.RightLabel == nil)) {
+		return domain.ErrInvalidMutation
+	}
+	return nil
+}
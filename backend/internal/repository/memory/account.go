@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.AccountRepository = (*AccountRepository)(nil)
+
+type AccountRepository struct {
+	store *Store
+}
+
+func NewAccountRepository(store *Store) *AccountRepository {
+	return &AccountRepository{store: store}
+}
+
+func (r *AccountRepository) Export(ctx context.Context, actorID uuid.UUID) (*domain.AccountData, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	actor, ok := r.store.actors[actorID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	data := &domain.AccountData{Actor: copyActor(actor)}
+
+	for _, device := range r.store.devices {
+		if device.ActorID == actorID {
+			data.Devices = append(data.Devices, copyDevice(device))
+		}
+	}
+	sort.Slice(data.Devices, func(i, j int) bool {
+		return oldestFirst(data.Devices[i].CreatedAt, data.Devices[i].ID, data.Devices[j].CreatedAt, data.Devices[j].ID)
+	})
+
+	for _, session := range r.store.sessions {
+		if session.ActorID == actorID {
+			s := *session
+			data.Sessions = append(data.Sessions, &s)
+		}
+	}
+	sort.Slice(data.Sessions, func(i, j int) bool {
+		return oldestFirst(data.Sessions[i].CreatedAt, data.Sessions[i].ID, data.Sessions[j].CreatedAt, data.Sessions[j].ID)
+	})
+
+	for _, fork := range r.store.forks {
+		if fork.CreatedByActorID == actorID {
+			data.Forks = append(data.Forks, r.store.forkWithStats(fork))
+		}
+	}
+	sort.Slice(data.Forks, func(i, j int) bool {
+		return oldestFirst(data.Forks[i].CreatedAt, data.Forks[i].ID, data.Forks[j].CreatedAt, data.Forks[j].ID)
+	})
+
+	for key, vote := range r.store.votes {
+		if key.actorID == actorID {
+			v := *vote
+			data.Votes = append(data.Votes, &v)
+		}
+	}
+	sort.Slice(data.Votes, func(i, j int) bool {
+		return oldestFirst(data.Votes[i].CreatedAt, data.Votes[i].ForkID, data.Votes[j].CreatedAt, data.Votes[j].ForkID)
+	})
+
+	// Reports are appended in creation order already
+	for _, report := range r.store.reports {
+		if report.ActorID == actorID {
+			data.Reports = append(data.Reports, copyReport(report))
+		}
+	}
+
+	return data, nil
+}
+
+// oldestFirst orders by (created_at, id) ascending
+func oldestFirst(a time.Time, aID uuid.UUID, b time.Time, bID uuid.UUID) bool {
+	if !a.Equal(b) {
+		return a.Before(b)
+	}
+	return bytes.Compare(aID[:], bID[:]) < 0
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, actorID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.actors[actorID]; !ok {
+		return domain.ErrNotFound
+	}
+
+	// Take the actor's votes and twists off the counters first, as the
+	// cascades below drop them
+	for key, vote := range r.store.votes {
+		if key.actorID == actorID {
+			r.store.incrementStats(key.forkID, vote.Type, -1)
+			delete(r.store.votes, key)
+		}
+	}
+	interactions := r.store.interactions[:0]
+	for _, interaction := range r.store.interactions {
+		if interaction.ActorID == actorID {
+			if interaction.Type == domain.InteractionTwist {
+				r.store.incrementStats(interaction.ForkID, interaction.Type, -1)
+			}
+			continue
+		}
+		interactions = append(interactions, interaction)
+	}
+	r.store.interactions = interactions
+
+	// Delete the forks nothing depends on, leaves first, and hand the rest
+	// to the placeholder actor
+	for r.deleteFreeForks(actorID) > 0 {
+	}
+	for _, fork := range r.store.forks {
+		if fork.CreatedByActorID == actorID {
+			fork.CreatedByActorID = domain.DeletedActorID
+			fork.CreatedByMaskID = nil
+		}
+	}
+
+	reports := r.store.reports[:0]
+	for _, report := range r.store.reports {
+		if report.ActorID == actorID {
+			r.detachActions(report.ID)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	r.store.reports = reports
+
+	for id, mask := range r.store.masks {
+		if mask.ActorID == actorID {
+			delete(r.store.masks, id)
+		}
+	}
+	for id, session := range r.store.sessions {
+		if session.ActorID == actorID {
+			delete(r.store.sessions, id)
+		}
+	}
+	for hash, token := range r.store.refreshTokens {
+		if _, ok := r.store.sessions[token.SessionID]; !ok {
+			delete(r.store.refreshTokens, hash)
+		}
+	}
+	for id, device := range r.store.devices {
+		if device.ActorID == actorID {
+			delete(r.store.devices, id)
+		}
+	}
+	// Exports hold the actor's data; only the deletion job is kept, to be
+	// polled
+	for id, job := range r.store.accountJobs {
+		if job.ActorID == actorID && job.Kind == domain.AccountJobExport {
+			delete(r.store.accountJobs, id)
+		}
+	}
+	delete(r.store.recoveryCodes, actorID)
+	delete(r.store.trust, actorID)
+	delete(r.store.actors, actorID)
+	return nil
+}
+
+// deleteFreeForks deletes the actor's forks that have no twists and no
+// moderation history, with everything that cascades from them, and returns
+// how many there were. Callers must hold the write lock.
+func (r *AccountRepository) deleteFreeForks(actorID uuid.UUID) int {
+	referenced := make(map[uuid.UUID]bool)
+	for _, fork := range r.store.forks {
+		if fork.ParentForkID != nil {
+			referenced[*fork.ParentForkID] = true
+		}
+	}
+	for _, action := range r.store.actions {
+		referenced[action.ForkID] = true
+	}
+
+	deleted := make(map[uuid.UUID]bool)
+	for id, fork := range r.store.forks {
+		if fork.CreatedByActorID == actorID && !referenced[id] {
+			deleted[id] = true
+		}
+	}
+	if len(deleted) == 0 {
+		return 0
+	}
+
+	for id := range deleted {
+		delete(r.store.forks, id)
+		delete(r.store.stats, id)
+	}
+	for key := range r.store.votes {
+		if deleted[key.forkID] {
+			delete(r.store.votes, key)
+		}
+	}
+	interactions := r.store.interactions[:0]
+	for _, interaction := range r.store.interactions {
+		if !deleted[interaction.ForkID] {
+			interactions = append(interactions, interaction)
+		}
+	}
+	r.store.interactions = interactions
+	reports := r.store.reports[:0]
+	for _, report := range r.store.reports {
+		if !deleted[report.ForkID] {
+			reports = append(reports, report)
+		}
+	}
+	r.store.reports = reports
+	return len(deleted)
+}
+
+// detachActions clears the report from moderation actions that cite it, as
+// ON DELETE SET NULL does. Callers must hold the write lock.
+func (r *AccountRepository) detachActions(reportID uuid.UUID) {
+	for _, action := range r.store.actions {
+		if action.ReportID != nil && *action.ReportID == reportID {
+			action.ReportID = nil
+		}
+	}
+}
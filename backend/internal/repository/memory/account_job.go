@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.AccountJobRepository = (*AccountJobRepository)(nil)
+
+type AccountJobRepository struct {
+	store *Store
+}
+
+func NewAccountJobRepository(store *Store) *AccountJobRepository {
+	return &AccountJobRepository{store: store}
+}
+
+func (r *AccountJobRepository) Create(ctx context.Context, job *domain.AccountJob) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.accountJobs[job.ID]; exists {
+		return domain.ErrInvalidInput
+	}
+	stored := copyAccountJob(job)
+	stored.CreatedAt = pgTime(stored.CreatedAt)
+	r.store.accountJobs[job.ID] = stored
+	return nil
+}
+
+func (r *AccountJobRepository) Get(ctx context.Context, id uuid.UUID) (*domain.AccountJob, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	job, ok := r.store.accountJobs[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return copyAccountJob(job), nil
+}
+
+func (r *AccountJobRepository) GetLatest(ctx context.Context, actorID uuid.UUID, kind string) (*domain.AccountJob, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var latest *domain.AccountJob
+	for _, job := range r.store.accountJobs {
+		if job.ActorID != actorID || job.Kind != kind {
+			continue
+		}
+		if latest == nil || oldestFirst(latest.CreatedAt, latest.ID, job.CreatedAt, job.ID) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return nil, domain.ErrNotFound
+	}
+	return copyAccountJob(latest), nil
+}
+
+func (r *AccountJobRepository) Claim(ctx context.Context, now, staleBefore time.Time) (*domain.AccountJob, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var next *domain.AccountJob
+	for _, job := range r.store.accountJobs {
+		claimable := job.Status == domain.AccountJobPending ||
+			(job.Status == domain.AccountJobRunning && job.StartedAt.Before(staleBefore))
+		if claimable && (next == nil || job.CreatedAt.Before(next.CreatedAt)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, domain.ErrNotFound
+	}
+	startedAt := pgTime(now)
+	next.Status = domain.AccountJobRunning
+	next.StartedAt = &startedAt
+	return copyAccountJob(next), nil
+}
+
+func (r *AccountJobRepository) Finish(ctx context.Context, id uuid.UUID, status string, archive []byte, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	job, ok := r.store.accountJobs[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	completedAt := pgTime(now)
+	job.Status = status
+	job.Archive = append([]byte(nil), archive...)
+	job.CompletedAt = &completedAt
+	return nil
+}
+
+func (r *AccountJobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deleted := 0
+	for id, job := range r.store.accountJobs {
+		if job.Finished() && job.CompletedAt.Before(before) {
+			delete(r.store.accountJobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.ActorRepository = (*ActorRepository)(nil)
+
+type ActorRepository struct {
+	store *Store
+}
+
+func NewActorRepository(store *Store) *ActorRepository {
+	return &ActorRepository{store: store}
+}
+
+func (r *ActorRepository) Create(ctx context.Context, actor *domain.Actor) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.actors[actor.ID]; exists {
+		return domain.ErrInvalidInput
+	}
+	r.store.actors[actor.ID] = copyActor(actor)
+	return nil
+}
+
+func (r *ActorRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Actor, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	actor, ok := r.store.actors[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return copyActor(actor), nil
+}
+
+func (r *ActorRepository) GetByDeviceFingerprint(ctx context.Context, fingerprint string) (*domain.Actor, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var newest *domain.Actor
+	for _, actor := range r.store.actors {
+		if actor.DeviceFingerprint == fingerprint && (newest == nil || actor.CreatedAt.After(newest.CreatedAt)) {
+			newest = actor
+		}
+	}
+	if newest == nil {
+		return nil, domain.ErrNotFound
+	}
+	return copyActor(newest), nil
+}
+
+func (r *ActorRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, audit *domain.AdminAuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	actor, ok := r.store.actors[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	actor.Status = status
+	r.store.appendAudit(audit)
+	return nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.ActorCacheStore = (*ActorCacheStore)(nil)
+
+type ActorCacheStore struct {
+	store *Store
+}
+
+func NewActorCacheStore(store *Store) *ActorCacheStore {
+	return &ActorCacheStore{store: store}
+}
+
+func (s *ActorCacheStore) Get(ctx context.Context, actorID uuid.UUID) (*domain.Actor, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	entry, ok := s.store.actorCache[actorID]
+	if !ok || !entry.live(time.Now()) {
+		return nil, domain.ErrNotFound
+	}
+	return copyActor(entry.value), nil
+}
+
+func (s *ActorCacheStore) Set(ctx context.Context, actor *domain.Actor, ttl time.Duration) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	s.store.actorCache[actor.ID] = expiring[*domain.Actor]{value: copyActor(actor), expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *ActorCacheStore) Delete(ctx context.Context, actorID uuid.UUID) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	delete(s.store.actorCache, actorID)
+	return nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.AdminAuditRepository = (*AdminAuditRepository)(nil)
+
+type AdminAuditRepository struct {
+	store *Store
+}
+
+func NewAdminAuditRepository(store *Store) *AdminAuditRepository {
+	return &AdminAuditRepository{store: store}
+}
+
+func (r *AdminAuditRepository) List(ctx context.Context, targetID *uuid.UUID, before *repository.AuditPosition, limit int) ([]*domain.AdminAuditEntry, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var entries []*domain.AdminAuditEntry
+	for _, entry := range r.store.audit {
+		if targetID != nil && entry.TargetID != *targetID {
+			continue
+		}
+		if before != nil && !auditBefore(entry, before) {
+			continue
+		}
+		c := *entry
+		entries = append(entries, &c)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return auditBefore(entries[j], &repository.AuditPosition{CreatedAt: entries[i].CreatedAt, ID: entries[i].ID})
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// auditBefore reports whether (entry.created_at, entry.id) < position,
+// matching the SQL ordering
+func auditBefore(entry *domain.AdminAuditEntry, position *repository.AuditPosition) bool {
+	if !entry.CreatedAt.Equal(position.CreatedAt) {
+		return entry.CreatedAt.Before(position.CreatedAt)
+	}
+	return bytes.Compare(entry.ID[:], position.ID[:]) < 0
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/repository"
+)
+
+var _ repository.ChallengeStore = (*ChallengeStore)(nil)
+
+type ChallengeStore struct {
+	store *Store
+}
+
+func NewChallengeStore(store *Store) *ChallengeStore {
+	return &ChallengeStore{store: store}
+}
+
+func (s *ChallengeStore) Put(ctx context.Context, nonce string, ttl time.Duration) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	s.store.challenges[nonce] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *ChallengeStore) Consume(ctx context.Context, nonce string) (bool, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	expiresAt, ok := s.store.challenges[nonce]
+	if !ok {
+		return false, nil
+	}
+	delete(s.store.challenges, nonce)
+	return time.Now().Before(expiresAt), nil
+}
@@ -0,0 +1,254 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.DeviceRepository = (*DeviceRepository)(nil)
+
+type DeviceRepository struct {
+	store *Store
+}
+
+func NewDeviceRepository(store *Store) *DeviceRepository {
+	return &DeviceRepository{store: store}
+}
+
+func (r *DeviceRepository) GetByKeyID(ctx context.Context, keyID string) (*domain.Device, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, device := range r.store.devices {
+		if device.KeyID == keyID {
+			return copyDevice(device), nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *DeviceRepository) ListByActor(ctx context.Context, actorID uuid.UUID) ([]*domain.Device, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var devices []*domain.Device
+	for _, device := range r.store.devices {
+		if device.ActorID == actorID {
+			devices = append(devices, copyDevice(device))
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].LastSeenAt.Equal(devices[j].LastSeenAt) {
+			return devices[i].LastSeenAt.After(devices[j].LastSeenAt)
+		}
+		return bytes.Compare(devices[i].ID[:], devices[j].ID[:]) < 0
+	})
+	return devices, nil
+}
+
+func (r *DeviceRepository) Register(ctx context.Context, device *domain.Device, newActor *domain.Actor, recoveryCodeHash string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if newActor != nil {
+		if _, exists := r.store.actors[newActor.ID]; exists {
+			return domain.ErrInvalidInput
+		}
+		device.ActorID = newActor.ID
+	} else if _, ok := r.store.actors[device.ActorID]; !ok {
+		return domain.ErrNotFound
+	}
+	if err := r.checkUnique(device); err != nil {
+		return err
+	}
+
+	if newActor != nil {
+		if err := r.checkRecoveryCode(newActor.ID, recoveryCodeHash); err != nil {
+			return err
+		}
+		r.store.actors[newActor.ID] = copyActor(newActor)
+		r.store.recoveryCodes[newActor.ID] = recoveryCodeHash
+	}
+	r.store.devices[device.ID] = storedDevice(device)
+	return nil
+}
+
+// checkRecoveryCode mirrors the unique code_hash constraint of the
+// recovery_codes table. Callers must hold the lock.
+func (r *DeviceRepository) checkRecoveryCode(actorID uuid.UUID, hash string) error {
+	for id, h := range r.store.recoveryCodes {
+		if h == hash && id != actorID {
+			return domain.ErrInvalidInput
+		}
+	}
+	return nil
+}
+
+// storedDevice copies the device as the database would store it
+func storedDevice(device *domain.Device) *domain.Device {
+	stored := copyDevice(device)
+	stored.CreatedAt = pgTime(stored.CreatedAt)
+	stored.LastSeenAt = pgTime(stored.LastSeenAt)
+	return stored
+}
+
+// checkUnique mirrors the primary key and key_id constraints of the devices
+// table. Callers must hold the lock.
+func (r *DeviceRepository) checkUnique(device *domain.Device) error {
+	if _, exists := r.store.devices[device.ID]; exists {
+		return domain.ErrInvalidInput
+	}
+	for _, d := range r.store.devices {
+		if d.KeyID == device.KeyID {
+			return domain.ErrInvalidInput
+		}
+	}
+	return nil
+}
+
+func (r *DeviceRepository) ClaimLegacyActor(ctx context.Context, device *domain.Device, refreshTokenHash, recoveryCodeHash string, now time.Time) (*domain.Actor, *domain.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.refreshTokens[refreshTokenHash]
+	if !ok {
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+	session := r.store.sessions[token.SessionID]
+
+	if session.RevokedAt != nil {
+		return nil, nil, domain.ErrSessionRevoked
+	}
+	if token.UsedAt != nil {
+		revokedAt := now
+		session.RevokedAt = &revokedAt
+		s := *session
+		return nil, &s, domain.ErrRefreshTokenReused
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+
+	actor, ok := r.store.actors[session.ActorID]
+	if !ok {
+		return nil, nil, domain.ErrNotFound
+	}
+	for _, d := range r.store.devices {
+		if d.ActorID == actor.ID {
+			return nil, nil, domain.ErrAlreadyClaimed
+		}
+	}
+	if err := r.checkUnique(device); err != nil {
+		return nil, nil, err
+	}
+	if err := r.checkRecoveryCode(actor.ID, recoveryCodeHash); err != nil {
+		return nil, nil, err
+	}
+
+	device.ActorID = actor.ID
+	r.store.devices[device.ID] = storedDevice(device)
+	r.store.recoveryCodes[actor.ID] = recoveryCodeHash
+	usedAt, revokedAt := now, now
+	token.UsedAt = &usedAt
+	session.RevokedAt = &revokedAt
+	s := *session
+	return copyActor(actor), &s, nil
+}
+
+func (r *DeviceRepository) Recover(ctx context.Context, device *domain.Device, recoveryCodeHash, nextRecoveryCodeHash string) (*domain.Actor, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var actor *domain.Actor
+	for actorID, hash := range r.store.recoveryCodes {
+		if hash == recoveryCodeHash {
+			actor = r.store.actors[actorID]
+			break
+		}
+	}
+	if actor == nil {
+		return nil, domain.ErrInvalidRecoveryCode
+	}
+	if err := r.checkUnique(device); err != nil {
+		return nil, err
+	}
+	if err := r.checkRecoveryCode(actor.ID, nextRecoveryCodeHash); err != nil {
+		return nil, err
+	}
+
+	device.ActorID = actor.ID
+	r.store.devices[device.ID] = storedDevice(device)
+	r.store.recoveryCodes[actor.ID] = nextRecoveryCodeHash
+	return copyActor(actor), nil
+}
+
+func (r *DeviceRepository) SetRecoveryCode(ctx context.Context, actorID uuid.UUID, recoveryCodeHash string, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.actors[actorID]; !ok {
+		return domain.ErrNotFound
+	}
+	if err := r.checkRecoveryCode(actorID, recoveryCodeHash); err != nil {
+		return err
+	}
+	r.store.recoveryCodes[actorID] = recoveryCodeHash
+	return nil
+}
+
+func (r *DeviceRepository) Touch(ctx context.Context, id uuid.UUID, fingerprint string, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	device, ok := r.store.devices[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	device.Fingerprint = fingerprint
+	device.LastSeenAt = pgTime(now)
+	return nil
+}
+
+func (r *DeviceRepository) Delete(ctx context.Context, actorID, id uuid.UUID) ([]uuid.UUID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	device, ok := r.store.devices[id]
+	if !ok || device.ActorID != actorID {
+		return nil, domain.ErrNotFound
+	}
+	devices := 0
+	for _, d := range r.store.devices {
+		if d.ActorID == actorID {
+			devices++
+		}
+	}
+	if devices <= 1 {
+		return nil, domain.ErrLastDevice
+	}
+
+	// Sessions and their refresh tokens go with the device
+	var sessionIDs []uuid.UUID
+	for sessionID, session := range r.store.sessions {
+		if session.DeviceID == nil || *session.DeviceID != id {
+			continue
+		}
+		if session.RevokedAt == nil {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+		delete(r.store.sessions, sessionID)
+	}
+	for hash, token := range r.store.refreshTokens {
+		if _, ok := r.store.sessions[token.SessionID]; !ok {
+			delete(r.store.refreshTokens, hash)
+		}
+	}
+	delete(r.store.devices, id)
+	return sessionIDs, nil
+}
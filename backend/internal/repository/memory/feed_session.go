@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.FeedSessionStore = (*FeedSessionStore)(nil)
+
+type FeedSessionStore struct {
+	store *Store
+}
+
+func NewFeedSessionStore(store *Store) *FeedSessionStore {
+	return &FeedSessionStore{store: store}
+}
+
+func (s *FeedSessionStore) Get(ctx context.Context, actorID uuid.UUID) (*domain.Intent, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	entry, ok := s.store.feedSessions[actorID]
+	if !ok || !entry.live(time.Now()) {
+		return nil, domain.ErrNotFound
+	}
+	intent := entry.value
+	return &intent, nil
+}
+
+func (s *FeedSessionStore) Set(ctx context.Context, actorID uuid.UUID, intent domain.Intent, ttl time.Duration) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	s.store.feedSessions[actorID] = expiring[domain.Intent]{value: intent, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *FeedSessionStore) AddSwitch(ctx context.Context, actorID uuid.UUID, sw domain.IntentSwitch, keep int, ttl time.Duration) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	now := time.Now()
+	var switches []domain.IntentSwitch
+	if entry, ok := s.store.feedHistory[actorID]; ok && entry.live(now) {
+		switches = entry.value
+	}
+	switches = append([]domain.IntentSwitch{sw}, switches...)
+	if len(switches) > keep {
+		switches = switches[:keep]
+	}
+	s.store.feedHistory[actorID] = expiring[[]domain.IntentSwitch]{value: switches, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *FeedSessionStore) Switches(ctx context.Context, actorID uuid.UUID, limit int) ([]domain.IntentSwitch, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	entry, ok := s.store.feedHistory[actorID]
+	if !ok || !entry.live(time.Now()) {
+		return nil, nil
+	}
+	switches := entry.value
+	if len(switches) > limit {
+		switches = switches[:limit]
+	}
+	return append([]domain.IntentSwitch(nil), switches...), nil
+}
+
+func (s *FeedSessionStore) Delete(ctx context.Context, actorID uuid.UUID) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	delete(s.store.feedSessions, actorID)
+	delete(s.store.feedHistory, actorID)
+	return nil
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.ForkRepository = (*ForkRepository)(nil)
+
+type ForkRepository struct {
+	store *Store
+}
+
+func NewForkRepository(store *Store) *ForkRepository {
+	return &ForkRepository{store: store}
+}
+
+func (r *ForkRepository) Create(ctx context.Context, fork *domain.Fork, since time.Time, limit int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.forks[fork.ID]; exists {
+		return domain.ErrInvalidInput
+	}
+	created := 0
+	for _, other := range r.store.forks {
+		if other.CreatedByActorID == fork.CreatedByActorID && !other.CreatedAt.Before(since) {
+			created++
+		}
+	}
+	if created >= limit {
+		return domain.ErrRateLimited
+	}
+	if fork.ParentForkID != nil {
+		parent, ok := r.store.forks[*fork.ParentForkID]
+		if !ok {
+			return domain.ErrParentUnavailable
+		}
+		if err := parent.CheckTwistable(); err != nil {
+			return err
+		}
+	}
+	stored := copyFork(fork)
+	stored.CreatedAt = pgTime(stored.CreatedAt)
+	r.store.forks[fork.ID] = stored
+
+	if fork.ParentForkID != nil {
+		r.store.interactions = append(r.store.interactions, &domain.Interaction{
+			ID:        uuid.New(),
+			ActorID:   fork.CreatedByActorID,
+			ForkID:    *fork.ParentForkID,
+			Type:      domain.InteractionTwist,
+			CreatedAt: fork.CreatedAt,
+		})
+		r.store.incrementStats(*fork.ParentForkID, domain.InteractionTwist, 1)
+	}
+	return nil
+}
+
+func (r *ForkRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Fork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	fork, ok := r.store.forks[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return r.store.forkWithStats(fork), nil
+}
+
+func (r *ForkRepository) GetFeed(ctx context.Context, lane, energy string, excludeIDs []uuid.UUID, after *repository.FeedPosition, limit int) ([]*domain.Fork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	excluded := make(map[uuid.UUID]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+
+	var matches []*domain.Fork
+	for _, fork := range r.store.forks {
+		if fork.Status != domain.ForkStatusVisible {
+			continue
+		}
+		if lane != "" && fork.IntentLane != lane {
+			continue
+		}
+		if energy != "" && fork.Energy != energy {
+			continue
+		}
+		if excluded[fork.ID] {
+			continue
+		}
+		if after != nil && !keysetBefore(fork, after.CreatedAt, after.ID) {
+			continue
+		}
+		matches = append(matches, fork)
+	}
+
+	sortNewestFirst(matches)
+
+	if limit >= 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	forks := make([]*domain.Fork, len(matches))
+	for i, fork := range matches {
+		forks[i] = r.store.forkWithStats(fork)
+	}
+	return forks, nil
+}
+
+func (r *ForkRepository) GetVisibleByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Fork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var matches []*domain.Fork
+	for _, id := range ids {
+		fork, ok := r.store.forks[id]
+		if ok && fork.Status == domain.ForkStatusVisible {
+			matches = append(matches, fork)
+		}
+	}
+
+	sortNewestFirst(matches)
+
+	forks := make([]*domain.Fork, len(matches))
+	for i, fork := range matches {
+		forks[i] = r.store.forkWithStats(fork)
+	}
+	return forks, nil
+}
+
+func (r *ForkRepository) GetByCreator(ctx context.Context, actorID uuid.UUID, after *repository.FeedPosition, limit int) ([]*domain.Fork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var matches []*domain.Fork
+	for _, fork := range r.store.forks {
+		if fork.CreatedByActorID != actorID {
+			continue
+		}
+		if after != nil && !keysetBefore(fork, after.CreatedAt, after.ID) {
+			continue
+		}
+		matches = append(matches, fork)
+	}
+
+	sortNewestFirst(matches)
+
+	if limit >= 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	forks := make([]*domain.Fork, len(matches))
+	for i, fork := range matches {
+		forks[i] = r.store.forkWithStats(fork)
+	}
+	return forks, nil
+}
+
+func (r *ForkRepository) GetAncestors(ctx context.Context, id uuid.UUID, maxDepth int) ([]*domain.Fork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	fork, ok := r.store.forks[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	chain := []*domain.Fork{r.store.forkWithStats(fork)}
+	for depth := 0; depth < maxDepth && fork.ParentForkID != nil; depth++ {
+		parent, ok := r.store.forks[*fork.ParentForkID]
+		if !ok {
+			break
+		}
+		chain = append(chain, r.store.forkWithStats(parent))
+		fork = parent
+	}
+
+	// Root first
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+func (r *ForkRepository) GetDescendants(ctx context.Context, id uuid.UUID, maxDepth int, limit int) ([]*domain.Fork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	children := make(map[uuid.UUID][]*domain.Fork)
+	for _, fork := range r.store.forks {
+		if fork.ParentForkID != nil {
+			children[*fork.ParentForkID] = append(children[*fork.ParentForkID], fork)
+		}
+	}
+
+	var forks []*domain.Fork
+	level := []uuid.UUID{id}
+	for depth := 1; depth <= maxDepth && len(level) > 0; depth++ {
+		var next []*domain.Fork
+		for _, parentID := range level {
+			next = append(next, children[parentID]...)
+		}
+		sort.Slice(next, func(i, j int) bool {
+			return keysetBefore(next[i], next[j].CreatedAt, next[j].ID)
+		})
+
+		level = level[:0]
+		for _, fork := range next {
+			if len(forks) == limit {
+				return forks, nil
+			}
+			forks = append(forks, r.store.forkWithStats(fork))
+			level = append(level, fork.ID)
+		}
+	}
+	return forks, nil
+}
+
+func (r *ForkRepository) GetCreatedTimesByActor(ctx context.Context, actorID uuid.UUID, since time.Time) ([]time.Time, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var times []time.Time
+	for _, fork := range r.store.forks {
+		if fork.CreatedByActorID == actorID && !fork.CreatedAt.Before(since) {
+			times = append(times, fork.CreatedAt)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	return times, nil
+}
+
+func (r *ForkRepository) CreateReport(ctx context.Context, report *domain.Report) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.forks[report.ForkID]; !ok {
+		return domain.ErrNotFound
+	}
+	for _, existing := range r.store.reports {
+		if existing.ActorID == report.ActorID && existing.ForkID == report.ForkID && domain.IsOpenReportState(existing.State) {
+			return domain.ErrDuplicateReport
+		}
+	}
+	stored := copyReport(report)
+	stored.CreatedAt = pgTime(stored.CreatedAt)
+	r.store.reports = append(r.store.reports, stored)
+	return nil
+}
+
+// sortNewestFirst orders forks by (created_at, id) DESC, matching the SQL
+// feed ordering.
+func sortNewestFirst(forks []*domain.Fork) {
+	sort.Slice(forks, func(i, j int) bool {
+		return keysetBefore(forks[j], forks[i].CreatedAt, forks[i].ID)
+	})
+}
+
+// keysetBefore reports whether fork sorts strictly after (createdAt, id) in
+// descending feed order, i.e. (fork.created_at, fork.id) < (createdAt, id).
+func keysetBefore(fork *domain.Fork, createdAt time.Time, id uuid.UUID) bool {
+	if !fork.CreatedAt.Equal(createdAt) {
+		return fork.CreatedAt.Before(createdAt)
+	}
+	return bytes.Compare(fork.ID[:], id[:]) < 0
+}
@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.InteractionRepository = (*InteractionRepository)(nil)
+
+type InteractionRepository struct {
+	store *Store
+}
+
+func NewInteractionRepository(store *Store) *InteractionRepository {
+	return &InteractionRepository{store: store}
+}
+
+func (r *InteractionRepository) Create(ctx context.Context, interaction *domain.Interaction) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if fork, ok := r.store.forks[interaction.ForkID]; !ok || fork.Status != domain.ForkStatusVisible {
+		return domain.ErrNotFound
+	}
+	r.store.interactions = append(r.store.interactions, copyInteraction(interaction))
+
+	if !domain.IsVoteType(interaction.Type) {
+		r.store.incrementStats(interaction.ForkID, interaction.Type, 1)
+		return nil
+	}
+
+	key := voteKey{actorID: interaction.ActorID, forkID: interaction.ForkID}
+	vote, ok := r.store.votes[key]
+	if !ok {
+		r.store.votes[key] = &domain.Vote{
+			ActorID:   interaction.ActorID,
+			ForkID:    interaction.ForkID,
+			Type:      interaction.Type,
+			CreatedAt: interaction.CreatedAt,
+			UpdatedAt: interaction.CreatedAt,
+		}
+		r.store.incrementStats(interaction.ForkID, interaction.Type, 1)
+		return nil
+	}
+
+	if vote.Type != interaction.Type {
+		r.store.incrementStats(interaction.ForkID, vote.Type, -1)
+		r.store.incrementStats(interaction.ForkID, interaction.Type, 1)
+		vote.Type = interaction.Type
+	}
+	vote.UpdatedAt = interaction.CreatedAt
+	return nil
+}
+
+func (r *InteractionRepository) GetVote(ctx context.Context, actorID, forkID uuid.UUID) (*domain.Vote, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	vote, ok := r.store.votes[voteKey{actorID: actorID, forkID: forkID}]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	v := *vote
+	return &v, nil
+}
+
+func (r *InteractionRepository) RetractVote(ctx context.Context, actorID, forkID uuid.UUID, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if fork, ok := r.store.forks[forkID]; !ok || fork.Status != domain.ForkStatusVisible {
+		return domain.ErrNotFound
+	}
+	key := voteKey{actorID: actorID, forkID: forkID}
+	vote, ok := r.store.votes[key]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	delete(r.store.votes, key)
+
+	r.store.interactions = append(r.store.interactions, &domain.Interaction{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		ForkID:    forkID,
+		Type:      domain.InteractionRetract,
+		CreatedAt: at,
+	})
+	r.store.incrementStats(forkID, vote.Type, -1)
+	return nil
+}
+
+func (r *InteractionRepository) GetByActor(ctx context.Context, actorID uuid.UUID, limit int) ([]*domain.Interaction, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var interactions []*domain.Interaction
+	for _, i := range r.store.interactions {
+		if i.ActorID == actorID {
+			interactions = append(interactions, copyInteraction(i))
+		}
+	}
+
+	sort.SliceStable(interactions, func(a, b int) bool {
+		return interactions[a].CreatedAt.After(interactions[b].CreatedAt)
+	})
+	if len(interactions) > limit {
+		interactions = interactions[:limit]
+	}
+	return interactions, nil
+}
+
+func (r *InteractionRepository) GetVoteHistory(ctx context.Context, actorID uuid.UUID, after *repository.VotePosition, limit int) ([]*domain.VotedFork, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var history []*domain.VotedFork
+	for key, vote := range r.store.votes {
+		if key.actorID != actorID {
+			continue
+		}
+		fork, ok := r.store.forks[key.forkID]
+		if !ok || fork.Status != domain.ForkStatusVisible {
+			continue
+		}
+		// Compare at stored precision so cursors round-trip
+		v := *vote
+		v.UpdatedAt = pgTime(v.UpdatedAt)
+		if after != nil && !voteBefore(&v, after.UpdatedAt, after.ForkID) {
+			continue
+		}
+		history = append(history, &domain.VotedFork{Vote: &v, Fork: r.store.forkWithStats(fork)})
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return voteBefore(history[j].Vote, history[i].Vote.UpdatedAt, history[i].Vote.ForkID)
+	})
+	if limit >= 0 && len(history) > limit {
+		history = history[:limit]
+	}
+	return history, nil
+}
+
+// voteBefore reports whether (vote.updated_at, vote.fork_id) < (updatedAt,
+// forkID), i.e. the vote comes later in the history ordering
+func voteBefore(vote *domain.Vote, updatedAt time.Time, forkID uuid.UUID) bool {
+	if !vote.UpdatedAt.Equal(updatedAt) {
+		return vote.UpdatedAt.Before(updatedAt)
+	}
+	return bytes.Compare(vote.ForkID[:], forkID[:]) < 0
+}
+
+func (r *InteractionRepository) GetActorStats(ctx context.Context, actorID uuid.UUID) (*domain.ActorStats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var stats domain.ActorStats
+	for key, vote := range r.store.votes {
+		if key.actorID != actorID {
+			continue
+		}
+		stats.Votes++
+
+		// Judge the majority from everyone else's votes
+		var othersLeft, othersRight int
+		if c, ok := r.store.stats[key.forkID]; ok {
+			othersLeft, othersRight = c.left, c.right
+		}
+		switch vote.Type {
+		case domain.InteractionSwipeLeft:
+			othersLeft--
+		case domain.InteractionSwipeRight:
+			othersRight--
+		default:
+			continue
+		}
+		if othersLeft == othersRight {
+			continue
+		}
+		stats.ContestedVotes++
+		if (vote.Type == domain.InteractionSwipeLeft) == (othersLeft > othersRight) {
+			stats.MajorityVotes++
+		}
+	}
+
+	for _, fork := range r.store.forks {
+		if fork.CreatedByActorID != actorID {
+			continue
+		}
+		if fork.ParentForkID == nil {
+			stats.ForksCreated++
+		} else {
+			stats.TwistsCreated++
+		}
+	}
+	return &stats, nil
+}
+
+func (r *InteractionRepository) GetSeenForkIDs(ctx context.Context, actorID uuid.UUID, since time.Time) ([]uuid.UUID, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, i := range r.store.interactions {
+		if i.ActorID != actorID || i.CreatedAt.Before(since) || seen[i.ForkID] {
+			continue
+		}
+		seen[i.ForkID] = true
+		ids = append(ids, i.ForkID)
+	}
+	return ids, nil
+}
+
+func (r *InteractionRepository) GetForkStats(ctx context.Context, forkID uuid.UUID) (left, right, skip, twist int, err error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if c, ok := r.store.stats[forkID]; ok {
+		left, right, skip, twist = c.left, c.right, c.skip, c.twist
+	}
+	return
+}
+
+func (r *InteractionRepository) RebuildForkStats(ctx context.Context) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	rebuilt := make(map[uuid.UUID]*forkStats, len(r.store.forks))
+	for id := range r.store.forks {
+		rebuilt[id] = &forkStats{}
+	}
+	for _, v := range r.store.votes {
+		if c, ok := rebuilt[v.ForkID]; ok {
+			c.add(v.Type, 1)
+		}
+	}
+
+	// Twist interactions without a matching twist fork don't count
+	twists := make(map[voteKey]int)
+	for _, fork := range r.store.forks {
+		if fork.ParentForkID != nil {
+			twists[voteKey{actorID: fork.CreatedByActorID, forkID: *fork.ParentForkID}]++
+		}
+	}
+	for _, i := range r.store.interactions {
+		key := voteKey{actorID: i.ActorID, forkID: i.ForkID}
+		if c, ok := rebuilt[i.ForkID]; ok && i.Type == domain.InteractionTwist && twists[key] > 0 {
+			twists[key]--
+			c.add(i.Type, 1)
+		}
+	}
+
+	corrected := 0
+	for id, c := range rebuilt {
+		if existing, ok := r.store.stats[id]; !ok || *existing != *c {
+			corrected++
+		}
+	}
+	r.store.stats = rebuilt
+	return corrected, nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.LinkCodeStore = (*LinkCodeStore)(nil)
+
+type LinkCodeStore struct {
+	store *Store
+}
+
+func NewLinkCodeStore(store *Store) *LinkCodeStore {
+	return &LinkCodeStore{store: store}
+}
+
+func (s *LinkCodeStore) Put(ctx context.Context, code string, actorID uuid.UUID, ttl time.Duration) (bool, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.store.linkCodes[code]; ok && existing.live(now) {
+		return false, nil
+	}
+	s.store.linkCodes[code] = expiring[uuid.UUID]{value: actorID, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *LinkCodeStore) Redeem(ctx context.Context, code string) (uuid.UUID, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	entry, ok := s.store.linkCodes[code]
+	if !ok {
+		return uuid.Nil, domain.ErrNotFound
+	}
+	delete(s.store.linkCodes, code)
+	if !entry.live(time.Now()) {
+		return uuid.Nil, domain.ErrNotFound
+	}
+	return entry.value, nil
+}
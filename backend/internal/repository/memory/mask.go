@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+)
+
+var _ repository.MaskRepository = (*MaskRepository)(nil)
+
+type MaskRepository struct {
+	store *Store
+}
+
+func NewMaskRepository(store *Store) *MaskRepository {
+	return &MaskRepository{store: store}
+}
+
+func (r *MaskRepository) GetOrCreateCurrent(ctx context.Context, candidate *domain.Mask, now time.Time) (*domain.Mask, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.actors[candidate.ActorID]; !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	var newest *domain.Mask
+	for _, mask := range r.store.masks {
+		if mask.ActorID != candidate.ActorID || mask.Lane != candidate.Lane {
+			continue
+		}
+		if newest == nil || mask.CreatedAt.After(newest.CreatedAt) {
+			newest = mask
+		}
+	}
+	if newest != nil && newest.ActiveAt(now) {
+		return copyMask(newest), nil
+	}
+
+	stored := copyMask(candidate)
+	stored.CreatedAt = pgTime(stored.CreatedAt)
+	r.store.masks[stored.ID] = stored
+	return copyMask(stored), nil
+}
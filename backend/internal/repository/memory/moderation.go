@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.ModerationRepository = (*ModerationRepository)(nil)
+
+type ModerationRepository struct {
+	store *Store
+}
+
+func NewModerationRepository(store *Store) *ModerationRepository {
+	return &ModerationRepository{store: store}
+}
+
+func (r *ModerationRepository) ListReports(ctx context.Context, state string, after *repository.ReportPosition, limit int) ([]*domain.Report, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var reports []*domain.Report
+	for _, report := range r.store.reports {
+		if report.State != state {
+			continue
+		}
+		if after != nil && !reportAfter(report, after) {
+			continue
+		}
+		reports = append(reports, copyReport(report))
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reportAfter(reports[j], &repository.ReportPosition{CreatedAt: reports[i].CreatedAt, ID: reports[i].ID})
+	})
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+	return reports, nil
+}
+
+// reportAfter reports whether (report.created_at, report.id) > position,
+// matching the SQL queue ordering.
+func reportAfter(report *domain.Report, position *repository.ReportPosition) bool {
+	if !report.CreatedAt.Equal(position.CreatedAt) {
+		return report.CreatedAt.After(position.CreatedAt)
+	}
+	return bytes.Compare(report.ID[:], position.ID[:]) > 0
+}
+
+func (r *ModerationRepository) GetReport(ctx context.Context, id uuid.UUID) (*domain.Report, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, report := range r.store.reports {
+		if report.ID == id {
+			return copyReport(report), nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *ModerationRepository) OpenReportWeight(ctx context.Context, forkID uuid.UUID) (float64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	weight := 0.0
+	for _, report := range r.store.reports {
+		if report.ForkID != forkID || !domain.IsOpenReportState(report.State) {
+			continue
+		}
+		if actor, ok := r.store.actors[report.ActorID]; ok {
+			weight += domain.ReportWeight(actor.TrustScore)
+		}
+	}
+	return weight, nil
+}
+
+func (r *ModerationRepository) TransitionReport(ctx context.Context, id uuid.UUID, state string, action *domain.ModerationAction, audit *domain.AdminAuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, report := range r.store.reports {
+		if report.ID != id {
+			continue
+		}
+		if !domain.ValidReportTransition(report.State, state) {
+			return domain.ErrInvalidTransition
+		}
+		report.State = state
+		action.ForkID = report.ForkID
+		r.store.appendAction(action)
+		r.store.appendAudit(audit)
+		return nil
+	}
+	return domain.ErrNotFound
+}
+
+func (r *ModerationRepository) TransitionFork(ctx context.Context, forkID uuid.UUID, status, reportState string, action *domain.ModerationAction, audit *domain.AdminAuditEntry) ([]*domain.Report, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	fork, ok := r.store.forks[forkID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if !domain.ValidForkTransition(fork.Status, status) {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	fork.Status = status
+	var settled []*domain.Report
+	if reportState != "" {
+		for _, report := range r.store.reports {
+			if report.ForkID == forkID && domain.IsOpenReportState(report.State) {
+				report.State = reportState
+				settled = append(settled, copyReport(report))
+			}
+		}
+	}
+	r.store.appendAction(action)
+	r.store.appendAudit(audit)
+	return settled, nil
+}
+
+func (r *ModerationRepository) GetReporterStats(ctx context.Context, actorID uuid.UUID) (domain.ReporterStats, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var stats domain.ReporterStats
+	for _, report := range r.store.reports {
+		if report.ActorID != actorID {
+			continue
+		}
+		switch report.State {
+		case domain.ReportStateDismissed:
+			stats.Dismissed++
+		case domain.ReportStateActioned:
+			stats.Actioned++
+		}
+	}
+	return stats, nil
+}
+
+func (r *ModerationRepository) ListActions(ctx context.Context, forkID uuid.UUID, limit int) ([]*domain.ModerationAction, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// The log is appended in time order, so walk it backwards
+	var actions []*domain.ModerationAction
+	for i := len(r.store.actions) - 1; i >= 0 && len(actions) < limit; i-- {
+		if action := r.store.actions[i]; action.ForkID == forkID {
+			c := *action
+			actions = append(actions, &c)
+		}
+	}
+	return actions, nil
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.RevocationStore = (*RevocationStore)(nil)
+
+type RevocationStore struct {
+	store *Store
+}
+
+func NewRevocationStore(store *Store) *RevocationStore {
+	return &RevocationStore{store: store}
+}
+
+func (s *RevocationStore) Revoke(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+
+	s.store.revoked[sessionID] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *RevocationStore) IsRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	expiresAt, ok := s.store.revoked[sessionID]
+	return ok && time.Now().Before(expiresAt), nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.SessionRepository = (*SessionRepository)(nil)
+
+type SessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) *SessionRepository {
+	return &SessionRepository{store: store}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.actors[session.ActorID]; !ok {
+		return domain.ErrNotFound
+	}
+	if session.DeviceID != nil {
+		if _, ok := r.store.devices[*session.DeviceID]; !ok {
+			return domain.ErrNotFound
+		}
+	}
+	if _, exists := r.store.sessions[session.ID]; exists {
+		return domain.ErrInvalidInput
+	}
+	s := *session
+	r.store.sessions[s.ID] = &s
+	t := *token
+	r.store.refreshTokens[t.Hash] = &t
+	return nil
+}
+
+func (r *SessionRepository) Get(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	s := *session
+	return &s, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, hash string, next *domain.RefreshToken, now time.Time) (*domain.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.refreshTokens[hash]
+	if !ok {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	session := r.store.sessions[token.SessionID]
+
+	if session.RevokedAt != nil {
+		return nil, domain.ErrSessionRevoked
+	}
+	if token.UsedAt != nil {
+		revokedAt := now
+		session.RevokedAt = &revokedAt
+		s := *session
+		return &s, domain.ErrRefreshTokenReused
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	usedAt := now
+	token.UsedAt = &usedAt
+	next.SessionID = session.ID
+	t := *next
+	r.store.refreshTokens[t.Hash] = &t
+
+	s := *session
+	return &s, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if session.RevokedAt == nil {
+		revokedAt := now
+		session.RevokedAt = &revokedAt
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeByActor(ctx context.Context, actorID uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var ids []uuid.UUID
+	for _, session := range r.store.sessions {
+		if session.ActorID == actorID && session.RevokedAt == nil {
+			revokedAt := now
+			session.RevokedAt = &revokedAt
+			ids = append(ids, session.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *SessionRepository) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return true, nil
+	}
+	return session.RevokedAt != nil, nil
+}
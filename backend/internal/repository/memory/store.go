@@ -0,0 +1,216 @@
+// Package memory provides concurrency-safe, in-process implementations of the
+// repository interfaces. It is intended for service tests and for running the
+// API without PostgreSQL or Redis; data does not survive a restart.
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Store holds the shared state behind the memory repositories. Repositories
+// created from the same Store see each other's writes, which lets fork reads
+// pick up the counters maintained by interaction writes the same way the SQL
+// joins against fork_stats do.
+type Store struct {
+	mu            sync.RWMutex
+	actors        map[uuid.UUID]*domain.Actor
+	masks         map[uuid.UUID]*domain.Mask
+	devices       map[uuid.UUID]*domain.Device
+	recoveryCodes map[uuid.UUID]string
+	sessions      map[uuid.UUID]*domain.Session
+	refreshTokens map[string]*domain.RefreshToken
+	forks         map[uuid.UUID]*domain.Fork
+	interactions  []*domain.Interaction
+	reports       []*domain.Report
+	actions       []*domain.ModerationAction
+	audit         []*domain.AdminAuditEntry
+	votes         map[voteKey]*domain.Vote
+	stats         map[uuid.UUID]*forkStats
+	trust         map[uuid.UUID]*domain.TrustScore
+	accountJobs   map[uuid.UUID]*domain.AccountJob
+
+	// Short-lived state, standing in for Redis
+	challenges   map[string]time.Time
+	linkCodes    map[string]expiring[uuid.UUID]
+	revoked      map[uuid.UUID]time.Time
+	actorCache   map[uuid.UUID]expiring[*domain.Actor]
+	feedSessions map[uuid.UUID]expiring[domain.Intent]
+	feedHistory  map[uuid.UUID]expiring[[]domain.IntentSwitch]
+}
+
+// expiring is a value that is dropped once now reaches expiresAt, as a
+// Redis key with a TTL would be
+type expiring[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func (e expiring[T]) live(now time.Time) bool {
+	return now.Before(e.expiresAt)
+}
+
+// voteKey mirrors the (actor_id, fork_id) primary key of the votes table
+type voteKey struct {
+	actorID uuid.UUID
+	forkID  uuid.UUID
+}
+
+// forkStats mirrors a row of the fork_stats table
+type forkStats struct {
+	left, right, skip, twist int
+}
+
+// add adjusts the counter matching interactionType by delta
+func (c *forkStats) add(interactionType string, delta int) {
+	switch interactionType {
+	case domain.InteractionSwipeLeft:
+		c.left += delta
+	case domain.InteractionSwipeRight:
+		c.right += delta
+	case domain.InteractionSkip:
+		c.skip += delta
+	case domain.InteractionTwist:
+		c.twist += delta
+	}
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		actors:        make(map[uuid.UUID]*domain.Actor),
+		masks:         make(map[uuid.UUID]*domain.Mask),
+		devices:       make(map[uuid.UUID]*domain.Device),
+		recoveryCodes: make(map[uuid.UUID]string),
+		sessions:      make(map[uuid.UUID]*domain.Session),
+		refreshTokens: make(map[string]*domain.RefreshToken),
+		forks:         make(map[uuid.UUID]*domain.Fork),
+		votes:         make(map[voteKey]*domain.Vote),
+		stats:         make(map[uuid.UUID]*forkStats),
+		trust:         make(map[uuid.UUID]*domain.TrustScore),
+		accountJobs:   make(map[uuid.UUID]*domain.AccountJob),
+		challenges:    make(map[string]time.Time),
+		linkCodes:     make(map[string]expiring[uuid.UUID]),
+		revoked:       make(map[uuid.UUID]time.Time),
+		actorCache:    make(map[uuid.UUID]expiring[*domain.Actor]),
+		feedSessions:  make(map[uuid.UUID]expiring[domain.Intent]),
+		feedHistory:   make(map[uuid.UUID]expiring[[]domain.IntentSwitch]),
+	}
+}
+
+// incrementStats bumps the materialized counter for an interaction.
+// Callers must hold the write lock.
+func (s *Store) incrementStats(forkID uuid.UUID, interactionType string, delta int) {
+	c, ok := s.stats[forkID]
+	if !ok {
+		c = &forkStats{}
+		s.stats[forkID] = c
+	}
+	c.add(interactionType, delta)
+}
+
+// appendAction adds a copy of the action to the audit log. Callers must hold
+// the write lock.
+func (s *Store) appendAction(action *domain.ModerationAction) {
+	c := *action
+	s.actions = append(s.actions, &c)
+}
+
+// appendAudit adds a copy of the entry to the admin audit log unless it is
+// nil. Callers must hold the write lock.
+func (s *Store) appendAudit(entry *domain.AdminAuditEntry) {
+	if entry == nil {
+		return
+	}
+	c := *entry
+	c.CreatedAt = pgTime(c.CreatedAt)
+	s.audit = append(s.audit, &c)
+}
+
+// forkWithStats returns a copy of the stored fork with stats and the mask
+// handle populated. Callers must hold at least a read lock.
+func (s *Store) forkWithStats(f *domain.Fork) *domain.Fork {
+	fork := copyFork(f)
+	if f.CreatedByMaskID != nil {
+		if mask, ok := s.masks[*f.CreatedByMaskID]; ok {
+			fork.MaskHandle = mask.Handle
+		}
+	}
+	if c, ok := s.stats[f.ID]; ok {
+		fork.LeftCount, fork.RightCount, fork.SkipCount, fork.TwistCount = c.left, c.right, c.skip, c.twist
+	}
+	return fork
+}
+
+// pgTime truncates t to the microsecond precision of a PostgreSQL timestamp,
+// so keyset cursors built from stored times round-trip exactly
+func pgTime(t time.Time) time.Time {
+	return t.Truncate(time.Microsecond)
+}
+
+func copyActor(a *domain.Actor) *domain.Actor {
+	c := *a
+	return &c
+}
+
+func copyMask(m *domain.Mask) *domain.Mask {
+	c := *m
+	if m.RotatesAt != nil {
+		rotatesAt := *m.RotatesAt
+		c.RotatesAt = &rotatesAt
+	}
+	return &c
+}
+
+func copyDevice(d *domain.Device) *domain.Device {
+	c := *d
+	c.PublicKey = append([]byte(nil), d.PublicKey...)
+	return &c
+}
+
+func copyFork(f *domain.Fork) *domain.Fork {
+	c := *f
+	if f.SafetyFlags != nil {
+		c.SafetyFlags = append([]string(nil), f.SafetyFlags...)
+	}
+	return &c
+}
+
+func copyReport(r *domain.Report) *domain.Report {
+	c := *r
+	return &c
+}
+
+func copyTrustScore(t *domain.TrustScore) *domain.TrustScore {
+	c := *t
+	c.Contributions = append([]domain.TrustContribution(nil), t.Contributions...)
+	if t.Override != nil {
+		override := *t.Override
+		c.Override = &override
+	}
+	return &c
+}
+
+func copyAccountJob(j *domain.AccountJob) *domain.AccountJob {
+	c := *j
+	if j.Archive != nil {
+		c.Archive = append([]byte(nil), j.Archive...)
+	}
+	if j.StartedAt != nil {
+		startedAt := *j.StartedAt
+		c.StartedAt = &startedAt
+	}
+	if j.CompletedAt != nil {
+		completedAt := *j.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	return &c
+}
+
+func copyInteraction(i *domain.Interaction) *domain.Interaction {
+	c := *i
+	return &c
+}
@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+var _ repository.TrustRepository = (*TrustRepository)(nil)
+
+type TrustRepository struct {
+	store *Store
+}
+
+func NewTrustRepository(store *Store) *TrustRepository {
+	return &TrustRepository{store: store}
+}
+
+func (r *TrustRepository) GetSignals(ctx context.Context, actorID uuid.UUID, reportsSince, swipesSince time.Time) (*domain.TrustSignals, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	actor, ok := r.store.actors[actorID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	signals := &domain.TrustSignals{ActorCreatedAt: actor.CreatedAt}
+
+	for _, fork := range r.store.forks {
+		if fork.CreatedByActorID != actorID {
+			continue
+		}
+		if c, ok := r.store.stats[fork.ID]; ok {
+			signals.Impressions += c.left + c.right + c.skip
+			signals.Skips += c.skip
+		}
+	}
+
+	for _, report := range r.store.reports {
+		if report.ActorID == actorID {
+			switch report.State {
+			case domain.ReportStateDismissed:
+				signals.Reporter.Dismissed++
+			case domain.ReportStateActioned:
+				signals.Reporter.Actioned++
+			}
+		}
+
+		fork, ok := r.store.forks[report.ForkID]
+		if !ok || fork.CreatedByActorID != actorID || report.CreatedAt.Before(reportsSince) {
+			continue
+		}
+		if report.State != domain.ReportStateDismissed {
+			signals.ReportsReceived++
+		}
+		if report.State == domain.ReportStateActioned {
+			signals.ReportsUpheld++
+		}
+	}
+
+	for _, interaction := range r.store.interactions {
+		if interaction.ActorID == actorID && domain.IsVoteType(interaction.Type) && !interaction.CreatedAt.Before(swipesSince) {
+			signals.RecentSwipes++
+		}
+	}
+
+	return signals, nil
+}
+
+func (r *TrustRepository) Get(ctx context.Context, actorID uuid.UUID) (*domain.TrustScore, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	score, ok := r.store.trust[actorID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return copyTrustScore(score), nil
+}
+
+func (r *TrustRepository) Save(ctx context.Context, score *domain.TrustScore) (*domain.TrustScore, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	actor, ok := r.store.actors[score.ActorID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	saved := copyTrustScore(score)
+	saved.ComputedAt = pgTime(saved.ComputedAt)
+	saved.Override = nil
+	if existing, ok := r.store.trust[score.ActorID]; ok {
+		saved.Override = existing.Override
+	}
+	r.store.trust[score.ActorID] = saved
+	actor.TrustScore = saved.Effective()
+	return copyTrustScore(saved), nil
+}
+
+func (r *TrustRepository) SetOverride(ctx context.Context, actorID uuid.UUID, override *float64, audit *domain.AdminAuditEntry) (*domain.TrustScore, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	actor, ok := r.store.actors[actorID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+
+	saved, ok := r.store.trust[actorID]
+	if !ok {
+		saved = &domain.TrustScore{ActorID: actorID}
+		r.store.trust[actorID] = saved
+	}
+	saved.Override = nil
+	if override != nil {
+		value := *override
+		saved.Override = &value
+	}
+
+	// Clearing an override before the actor was ever scored leaves their
+	// trust score alone until the next computation
+	if saved.Override != nil || !saved.ComputedAt.IsZero() {
+		actor.TrustScore = saved.Effective()
+	}
+	r.store.appendAudit(audit)
+	return copyTrustScore(saved), nil
+}
+
+func (r *TrustRepository) ListStale(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	type stale struct {
+		id         uuid.UUID
+		computedAt time.Time
+	}
+	var actors []stale
+	for id := range r.store.actors {
+		var computedAt time.Time
+		if score, ok := r.store.trust[id]; ok {
+			computedAt = score.ComputedAt
+		}
+		if computedAt.IsZero() || computedAt.Before(before) {
+			actors = append(actors, stale{id: id, computedAt: computedAt})
+		}
+	}
+
+	// Never-scored actors have a zero time and so sort first
+	sort.Slice(actors, func(i, j int) bool {
+		if !actors[i].computedAt.Equal(actors[j].computedAt) {
+			return actors[i].computedAt.Before(actors[j].computedAt)
+		}
+		return bytes.Compare(actors[i].id[:], actors[j].id[:]) < 0
+	})
+	if len(actors) > limit {
+		actors = actors[:limit]
+	}
+
+	ids := make([]uuid.UUID, len(actors))
+	for i, a := range actors {
+		ids[i] = a.id
+	}
+	return ids, nil
+}
@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.AccountRepository = (*AccountRepository)(nil)
+
+type AccountRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAccountRepository(db *pgxpool.Pool) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+func (r *AccountRepository) Export(ctx context.Context, actorID uuid.UUID) (*domain.AccountData, error) {
+	// One snapshot for every table, so the archive is consistent
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var data domain.AccountData
+	var actor domain.Actor
+	err = tx.QueryRow(ctx, `
+		SELECT id, device_fingerprint, trust_score, status, created_at
+		FROM actors
+		WHERE id = $1
+	`, actorID).Scan(
+		&actor.ID,
+		&actor.DeviceFingerprint,
+		&actor.TrustScore,
+		&actor.Status,
+		&actor.CreatedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	data.Actor = &actor
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+deviceColumns+`
+		FROM devices
+		WHERE actor_id = $1
+		ORDER BY created_at, id
+	`, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Devices = append(data.Devices, device)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT id, actor_id, device_id, created_at, revoked_at
+		FROM auth_sessions
+		WHERE actor_id = $1
+		ORDER BY created_at, id
+	`, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var session domain.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.ActorID,
+			&session.DeviceID,
+			&session.CreatedAt,
+			&session.RevokedAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Sessions = append(data.Sessions, &session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT `+forkColumns+`
+		FROM forks f
+		LEFT JOIN fork_stats stats ON stats.fork_id = f.id
+		WHERE f.created_by_actor_id = $1
+		ORDER BY f.created_at, f.id
+	`, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		fork, err := scanFork(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Forks = append(data.Forks, fork)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT actor_id, fork_id, vote_type, created_at, updated_at
+		FROM votes
+		WHERE actor_id = $1
+		ORDER BY created_at, fork_id
+	`, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var vote domain.Vote
+		err := rows.Scan(
+			&vote.ActorID,
+			&vote.ForkID,
+			&vote.Type,
+			&vote.CreatedAt,
+			&vote.UpdatedAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Votes = append(data.Votes, &vote)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+		SELECT id, actor_id, fork_id, reason, state, created_at
+		FROM reports
+		WHERE actor_id = $1
+		ORDER BY created_at, id
+	`, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Reports = append(data.Reports, report)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+func (r *AccountRepository) Delete(ctx context.Context, actorID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the actor so nothing new is attributed to them meanwhile
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `SELECT id FROM actors WHERE id = $1 FOR UPDATE`, actorID).Scan(&id)
+	if err != nil {
+		return translateError(err)
+	}
+
+	// Their votes and twists are about to cascade away; keep the counters
+	// of the forks that remain in step, as RebuildForkStats would count them
+	_, err = tx.Exec(ctx, `
+		UPDATE fork_stats s SET
+			left_count = s.left_count - d.left_count,
+			right_count = s.right_count - d.right_count,
+			skip_count = s.skip_count - d.skip_count,
+			twist_count = s.twist_count - d.twist_count,
+			updated_at = NOW()
+		FROM (
+			SELECT
+				fork_id,
+				COUNT(*) FILTER (WHERE kind = 'swipe_left') as left_count,
+				COUNT(*) FILTER (WHERE kind = 'swipe_right') as right_count,
+				COUNT(*) FILTER (WHERE kind = 'skip') as skip_count,
+				COUNT(*) FILTER (WHERE kind = 'twist') as twist_count
+			FROM (
+				SELECT fork_id, vote_type as kind FROM votes WHERE actor_id = $1
+				UNION ALL
+				SELECT fork_id, interaction_type FROM interactions
+				WHERE actor_id = $1 AND interaction_type = 'twist'
+			) contributions
+			GROUP BY fork_id
+		) d
+		WHERE s.fork_id = d.fork_id
+	`, actorID)
+	if err != nil {
+		return err
+	}
+
+	// Delete the forks nothing depends on, leaves first: removing a twist
+	// can free its parent for the next round
+	for {
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM forks f
+			WHERE f.created_by_actor_id = $1
+			  AND NOT EXISTS (SELECT 1 FROM forks c WHERE c.parent_fork_id = f.id)
+			  AND NOT EXISTS (SELECT 1 FROM moderation_actions m WHERE m.fork_id = f.id)
+		`, actorID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			break
+		}
+	}
+
+	// The rest stay, detached from the actor and their masks
+	_, err = tx.Exec(ctx, `
+		UPDATE forks SET created_by_actor_id = $2, created_by_mask_id = NULL
+		WHERE created_by_actor_id = $1
+	`, actorID, domain.DeletedActorID)
+	if err != nil {
+		return err
+	}
+
+	// Jobs don't reference actors, so nothing cascades to them. Exports
+	// hold the actor's data; only the deletion job is kept, to be polled.
+	_, err = tx.Exec(ctx, `
+		DELETE FROM account_jobs WHERE actor_id = $1 AND kind = $2
+	`, actorID, domain.AccountJobExport)
+	if err != nil {
+		return err
+	}
+
+	// Everything else the actor owns cascades
+	if _, err := tx.Exec(ctx, `DELETE FROM actors WHERE id = $1`, actorID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
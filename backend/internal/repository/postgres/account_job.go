@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.AccountJobRepository = (*AccountJobRepository)(nil)
+
+type AccountJobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAccountJobRepository(db *pgxpool.Pool) *AccountJobRepository {
+	return &AccountJobRepository{db: db}
+}
+
+const accountJobColumns = `id, actor_id, kind, status, archive, created_at, started_at, completed_at`
+
+func scanAccountJob(row pgx.Row) (*domain.AccountJob, error) {
+	var job domain.AccountJob
+	err := row.Scan(
+		&job.ID,
+		&job.ActorID,
+		&job.Kind,
+		&job.Status,
+		&job.Archive,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *AccountJobRepository) Create(ctx context.Context, job *domain.AccountJob) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO account_jobs (id, actor_id, kind, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, job.ID, job.ActorID, job.Kind, job.Status, job.CreatedAt)
+	return translateError(err)
+}
+
+func (r *AccountJobRepository) Get(ctx context.Context, id uuid.UUID) (*domain.AccountJob, error) {
+	job, err := scanAccountJob(r.db.QueryRow(ctx, `
+		SELECT `+accountJobColumns+`
+		FROM account_jobs
+		WHERE id = $1
+	`, id))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return job, nil
+}
+
+func (r *AccountJobRepository) GetLatest(ctx context.Context, actorID uuid.UUID, kind string) (*domain.AccountJob, error) {
+	job, err := scanAccountJob(r.db.QueryRow(ctx, `
+		SELECT `+accountJobColumns+`
+		FROM account_jobs
+		WHERE actor_id = $1 AND kind = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, actorID, kind))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return job, nil
+}
+
+func (r *AccountJobRepository) Claim(ctx context.Context, now, staleBefore time.Time) (*domain.AccountJob, error) {
+	// SKIP LOCKED lets concurrent workers each take a different job
+	job, err := scanAccountJob(r.db.QueryRow(ctx, `
+		UPDATE account_jobs SET status = 'running', started_at = $1
+		WHERE id = (
+			SELECT id FROM account_jobs
+			WHERE status = 'pending' OR (status = 'running' AND started_at < $2)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+accountJobColumns+`
+	`, now, staleBefore))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return job, nil
+}
+
+func (r *AccountJobRepository) Finish(ctx context.Context, id uuid.UUID, status string, archive []byte, now time.Time) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE account_jobs SET status = $2, archive = $3, completed_at = $4
+		WHERE id = $1
+	`, id, status, archive, now)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *AccountJobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	tag, err := r.db.Exec(ctx, `
+		DELETE FROM account_jobs
+		WHERE status IN ('completed', 'failed') AND completed_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
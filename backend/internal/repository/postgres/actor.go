@@ -2,12 +2,17 @@ package postgres
 
 import (
 	"context"
+	"errors"
 
 	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var _ repository.ActorRepository = (*ActorRepository)(nil)
+
 type ActorRepository struct {
 	db *pgxpool.Pool
 }
@@ -46,6 +51,9 @@ func (r *ActorRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Ac
 		&actor.CreatedAt,
 	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
 		return nil, err
 	}
 	return &actor, nil
@@ -56,6 +64,8 @@ func (r *ActorRepository) GetByDeviceFingerprint(ctx context.Context, fingerprin
 		SELECT id, device_fingerprint, trust_score, status, created_at
 		FROM actors
 		WHERE device_fingerprint = $1
+		ORDER BY created_at DESC
+		LIMIT 1
 	`
 	var actor domain.Actor
 	err := r.db.QueryRow(ctx, query, fingerprint).Scan(
@@ -66,27 +76,36 @@ func (r *ActorRepository) GetByDeviceFingerprint(ctx context.Context, fingerprin
 		&actor.CreatedAt,
 	)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
 		return nil, err
 	}
 	return &actor, nil
 }
 
-func (r *ActorRepository) UpdateTrustScore(ctx context.Context, id uuid.UUID, score float64) error {
-	query := `
-		UPDATE actors
-		SET trust_score = $2
-		WHERE id = $1
-	`
-	_, err := r.db.Exec(ctx, query, id, score)
-	return err
-}
+func (r *ActorRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string, audit *domain.AdminAuditEntry) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-func (r *ActorRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	query := `
 		UPDATE actors
 		SET status = $2
 		WHERE id = $1
 	`
-	_, err := r.db.Exec(ctx, query, id, status)
-	return err
+	tag, err := tx.Exec(ctx, query, id, status)
+	if err != nil {
+		return translateError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	if err := insertAuditEntry(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
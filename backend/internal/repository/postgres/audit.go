@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.AdminAuditRepository = (*AdminAuditRepository)(nil)
+
+type AdminAuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAdminAuditRepository(db *pgxpool.Pool) *AdminAuditRepository {
+	return &AdminAuditRepository{db: db}
+}
+
+func (r *AdminAuditRepository) List(ctx context.Context, targetID *uuid.UUID, before *repository.AuditPosition, limit int) ([]*domain.AdminAuditEntry, error) {
+	query := `
+		SELECT id, staff_id, action, target_type, target_id, details, created_at
+		FROM admin_audit_log
+		WHERE ($1::uuid IS NULL OR target_id = $1)
+		  AND ($2::timestamptz IS NULL OR (created_at, id) < ($2::timestamptz, $3::uuid))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	var beforeCreatedAt *time.Time
+	var beforeID *uuid.UUID
+	if before != nil {
+		beforeCreatedAt = &before.CreatedAt
+		beforeID = &before.ID
+	}
+
+	rows, err := r.db.Query(ctx, query, targetID, beforeCreatedAt, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.AdminAuditEntry
+	for rows.Next() {
+		var entry domain.AdminAuditEntry
+		var details []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.StaffID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &entry.Details); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// insertAuditEntry appends entry to the admin audit log as part of tx. A nil
+// entry records nothing.
+func insertAuditEntry(ctx context.Context, tx pgx.Tx, entry *domain.AdminAuditEntry) error {
+	if entry == nil {
+		return nil
+	}
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO admin_audit_log (id, staff_id, action, target_type, target_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = tx.Exec(ctx, query,
+		entry.ID,
+		entry.StaffID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		details,
+		entry.CreatedAt,
+	)
+	return translateError(err)
+}
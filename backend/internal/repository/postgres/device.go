@@ -0,0 +1,369 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.DeviceRepository = (*DeviceRepository)(nil)
+
+type DeviceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDeviceRepository(db *pgxpool.Pool) *DeviceRepository {
+	return &DeviceRepository{db: db}
+}
+
+const deviceColumns = `id, actor_id, key_id, public_key, fingerprint, attestation_format, created_at, last_seen_at`
+
+func scanDevice(row pgx.Row) (*domain.Device, error) {
+	var device domain.Device
+	var attestationFormat *string
+	err := row.Scan(
+		&device.ID,
+		&device.ActorID,
+		&device.KeyID,
+		&device.PublicKey,
+		&device.Fingerprint,
+		&attestationFormat,
+		&device.CreatedAt,
+		&device.LastSeenAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if attestationFormat != nil {
+		device.AttestationFormat = *attestationFormat
+	}
+	return &device, nil
+}
+
+func (r *DeviceRepository) GetByKeyID(ctx context.Context, keyID string) (*domain.Device, error) {
+	device, err := scanDevice(r.db.QueryRow(ctx, `
+		SELECT `+deviceColumns+`
+		FROM devices
+		WHERE key_id = $1
+	`, keyID))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return device, nil
+}
+
+func (r *DeviceRepository) ListByActor(ctx context.Context, actorID uuid.UUID) ([]*domain.Device, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+deviceColumns+`
+		FROM devices
+		WHERE actor_id = $1
+		ORDER BY last_seen_at DESC, id
+	`, actorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*domain.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
+func (r *DeviceRepository) Register(ctx context.Context, device *domain.Device, newActor *domain.Actor, recoveryCodeHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if newActor != nil {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO actors (id, device_fingerprint, trust_score, status, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, newActor.ID, newActor.DeviceFingerprint, newActor.TrustScore, newActor.Status, newActor.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := setRecoveryCode(ctx, tx, newActor.ID, recoveryCodeHash, newActor.CreatedAt); err != nil {
+			return err
+		}
+		device.ActorID = newActor.ID
+	}
+	if err := insertDevice(ctx, tx, device); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const upsertRecoveryCode = `
+	INSERT INTO recovery_codes (actor_id, code_hash, created_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (actor_id) DO UPDATE
+	SET code_hash = EXCLUDED.code_hash, created_at = EXCLUDED.created_at
+`
+
+func setRecoveryCode(ctx context.Context, tx pgx.Tx, actorID uuid.UUID, hash string, now time.Time) error {
+	_, err := tx.Exec(ctx, upsertRecoveryCode, actorID, hash, now)
+	return translateError(err)
+}
+
+func insertDevice(ctx context.Context, tx pgx.Tx, device *domain.Device) error {
+	var attestationFormat *string
+	if device.AttestationFormat != "" {
+		attestationFormat = &device.AttestationFormat
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO devices (id, actor_id, key_id, public_key, fingerprint, attestation_format, created_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		device.ID,
+		device.ActorID,
+		device.KeyID,
+		device.PublicKey,
+		device.Fingerprint,
+		attestationFormat,
+		device.CreatedAt,
+		device.LastSeenAt,
+	)
+	return translateError(err)
+}
+
+func (r *DeviceRepository) ClaimLegacyActor(ctx context.Context, device *domain.Device, refreshTokenHash, recoveryCodeHash string, now time.Time) (*domain.Actor, *domain.Session, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var session domain.Session
+	var expiresAt time.Time
+	var usedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT s.id, s.actor_id, s.device_id, s.created_at, s.revoked_at, t.expires_at, t.used_at
+		FROM refresh_tokens t
+		JOIN auth_sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, s
+	`, refreshTokenHash).Scan(
+		&session.ID,
+		&session.ActorID,
+		&session.DeviceID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+		&expiresAt,
+		&usedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, nil, domain.ErrSessionRevoked
+	}
+	if usedAt != nil {
+		// A replayed token can't prove anything; treat it as Rotate does
+		if _, err := tx.Exec(ctx, `UPDATE auth_sessions SET revoked_at = $2 WHERE id = $1`, session.ID, now); err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, nil, err
+		}
+		session.RevokedAt = &now
+		return nil, &session, domain.ErrRefreshTokenReused
+	}
+	if !now.Before(expiresAt) {
+		return nil, nil, domain.ErrInvalidRefreshToken
+	}
+
+	var actor domain.Actor
+	err = tx.QueryRow(ctx, `
+		SELECT id, device_fingerprint, trust_score, status, created_at
+		FROM actors
+		WHERE id = $1
+		FOR UPDATE
+	`, session.ActorID).Scan(
+		&actor.ID,
+		&actor.DeviceFingerprint,
+		&actor.TrustScore,
+		&actor.Status,
+		&actor.CreatedAt,
+	)
+	if err != nil {
+		return nil, nil, translateError(err)
+	}
+
+	// Checked under the actor lock, so of concurrent claims only one sees
+	// no devices
+	var claimed bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM devices WHERE actor_id = $1)`, actor.ID).Scan(&claimed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if claimed {
+		return nil, nil, domain.ErrAlreadyClaimed
+	}
+
+	device.ActorID = actor.ID
+	if err := insertDevice(ctx, tx, device); err != nil {
+		return nil, nil, err
+	}
+	if err := setRecoveryCode(ctx, tx, actor.ID, recoveryCodeHash, device.CreatedAt); err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = $2 WHERE token_hash = $1`, refreshTokenHash, now); err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE auth_sessions SET revoked_at = $2 WHERE id = $1`, session.ID, now); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	session.RevokedAt = &now
+	return &actor, &session, nil
+}
+
+func (r *DeviceRepository) Recover(ctx context.Context, device *domain.Device, recoveryCodeHash, nextRecoveryCodeHash string) (*domain.Actor, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Replacing the code in the same statement that finds it makes
+	// concurrent redemptions of one code serialize, and only the first
+	// finds it
+	var actor domain.Actor
+	err = tx.QueryRow(ctx, `
+		WITH redeemed AS (
+			UPDATE recovery_codes SET code_hash = $2, created_at = $3
+			WHERE code_hash = $1
+			RETURNING actor_id
+		)
+		SELECT a.id, a.device_fingerprint, a.trust_score, a.status, a.created_at
+		FROM actors a
+		JOIN redeemed r ON r.actor_id = a.id
+	`, recoveryCodeHash, nextRecoveryCodeHash, device.CreatedAt).Scan(
+		&actor.ID,
+		&actor.DeviceFingerprint,
+		&actor.TrustScore,
+		&actor.Status,
+		&actor.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidRecoveryCode
+		}
+		return nil, err
+	}
+
+	device.ActorID = actor.ID
+	if err := insertDevice(ctx, tx, device); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &actor, nil
+}
+
+func (r *DeviceRepository) SetRecoveryCode(ctx context.Context, actorID uuid.UUID, recoveryCodeHash string, now time.Time) error {
+	_, err := r.db.Exec(ctx, upsertRecoveryCode, actorID, recoveryCodeHash, now)
+	return translateError(err)
+}
+
+func (r *DeviceRepository) Touch(ctx context.Context, id uuid.UUID, fingerprint string, now time.Time) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE devices SET fingerprint = $2, last_seen_at = $3
+		WHERE id = $1
+	`, id, fingerprint, now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *DeviceRepository) Delete(ctx context.Context, actorID, id uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the actor so concurrent unlinks can't remove their last two
+	// devices at once
+	var devices int
+	err = tx.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM devices d WHERE d.actor_id = a.id)
+		FROM actors a
+		WHERE a.id = $1
+		FOR UPDATE
+	`, actorID).Scan(&devices)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM devices WHERE id = $1 AND actor_id = $2)`, id, actorID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+	if devices <= 1 {
+		return nil, domain.ErrLastDevice
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM auth_sessions
+		WHERE device_id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	var sessionIDs []uuid.UUID
+	for rows.Next() {
+		var sessionID uuid.UUID
+		if err := rows.Scan(&sessionID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Sessions and their refresh tokens go with the device
+	if _, err := tx.Exec(ctx, `DELETE FROM devices WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return sessionIDs, nil
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgreSQL error codes we translate
+const (
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+	uniqueViolation     = "23505"
+)
+
+// translateError maps driver errors onto domain errors so callers never
+// need to know about pgx: missing rows and dangling references become
+// domain.ErrNotFound, rejected values become domain.ErrInvalidInput.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case foreignKeyViolation:
+			return fmt.Errorf("%w: %s", domain.ErrNotFound, pgErr.ConstraintName)
+		case checkViolation:
+			return fmt.Errorf("%w: %s", domain.ErrInvalidInput, pgErr.ConstraintName)
+		}
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err violates the named unique constraint
+// or index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
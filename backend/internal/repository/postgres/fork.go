@@ -2,13 +2,19 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var _ repository.ForkRepository = (*ForkRepository)(nil)
+
 type ForkRepository struct {
 	db *pgxpool.Pool
 }
@@ -17,18 +23,71 @@ func NewForkRepository(db *pgxpool.Pool) *ForkRepository {
 	return &ForkRepository{db: db}
 }
 
-func (r *ForkRepository) Create(ctx context.Context, fork *domain.Fork) error {
+// Create inserts the fork. For a twist, the parent is locked and rechecked
+// and a twist interaction by the creator is recorded on it in the same
+// transaction.
+func (r *ForkRepository) Create(ctx context.Context, fork *domain.Fork, since time.Time, limit int) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the creator so their concurrent creations are counted one at a
+	// time. NO KEY UPDATE still lets rows referencing the actor be written.
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM actors WHERE id = $1 FOR NO KEY UPDATE
+	`, fork.CreatedByActorID).Scan(&id)
+	if err != nil {
+		return translateError(err)
+	}
+	var created int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM forks WHERE created_by_actor_id = $1 AND created_at >= $2
+	`, fork.CreatedByActorID, since).Scan(&created)
+	if err != nil {
+		return err
+	}
+	if created >= limit {
+		return domain.ErrRateLimited
+	}
+
+	if fork.ParentForkID != nil {
+		var parent domain.Fork
+		err := tx.QueryRow(ctx, `
+			SELECT status, safety_age_gate FROM forks WHERE id = $1 FOR SHARE
+		`, *fork.ParentForkID).Scan(&parent.Status, &parent.SafetyAgeGate)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrParentUnavailable
+			}
+			return err
+		}
+		if err := parent.CheckTwistable(); err != nil {
+			return err
+		}
+	}
+
 	query := `
 		INSERT INTO forks (
 			id, prompt, left_label, right_label, left_asset_id, right_asset_id,
 			intent_lane, mood, energy, time_fit_s, cognitive_load,
 			parent_fork_id, mutation_type, safety_age_gate, safety_sensitivity,
-			safety_flags, created_by_actor_id, created_by_mask_id, created_at
+			safety_flags, created_by_actor_id, created_by_mask_id, created_at,
+			mutation_diff, status
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
 		)
 	`
-	_, err := r.db.Exec(ctx, query,
+	var mutationDiff []byte
+	if fork.MutationDiff != nil {
+		if mutationDiff, err = json.Marshal(fork.MutationDiff); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, query,
 		fork.ID,
 		fork.Prompt,
 		fork.LeftLabel,
@@ -48,72 +107,89 @@ func (r *ForkRepository) Create(ctx context.Context, fork *domain.Fork) error {
 		fork.CreatedByActorID,
 		fork.CreatedByMaskID,
 		fork.CreatedAt,
+		mutationDiff,
+		fork.Status,
 	)
-	return err
+	if err != nil {
+		return translateError(err)
+	}
+
+	if fork.ParentForkID != nil {
+		twist := &domain.Interaction{
+			ID:        uuid.New(),
+			ActorID:   fork.CreatedByActorID,
+			ForkID:    *fork.ParentForkID,
+			Type:      domain.InteractionTwist,
+			CreatedAt: fork.CreatedAt,
+		}
+		if err := insertInteraction(ctx, tx, twist); err != nil {
+			return translateError(err)
+		}
+		if err := incrementForkStats(ctx, tx, twist.ForkID, twist.Type, 1); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (r *ForkRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Fork, error) {
-	query := `
-		SELECT
-			f.id, f.prompt, f.left_label, f.right_label, f.left_asset_id, f.right_asset_id,
-			f.intent_lane, f.mood, f.energy, f.time_fit_s, f.cognitive_load,
-			f.parent_fork_id, f.mutation_type, f.safety_age_gate, f.safety_sensitivity,
-			f.safety_flags, f.created_by_actor_id, f.created_by_mask_id, f.created_at,
-			COALESCE(stats.left_count, 0) as left_count,
-			COALESCE(stats.right_count, 0) as right_count,
-			COALESCE(stats.skip_count, 0) as skip_count,
-			COALESCE(stats.twist_count, 0) as twist_count
-		FROM forks f
-		LEFT JOIN (
-			SELECT
-				fork_id,
-				COUNT(*) FILTER (WHERE interaction_type = 'swipe_left') as left_count,
-				COUNT(*) FILTER (WHERE interaction_type = 'swipe_right') as right_count,
-				COUNT(*) FILTER (WHERE interaction_type = 'skip') as skip_count,
-				COUNT(*) FILTER (WHERE interaction_type = 'twist') as twist_count
-			FROM interactions
-			GROUP BY fork_id
-		) stats ON f.id = stats.fork_id
-		WHERE f.id = $1
-	`
+// forkColumns selects a fork with its materialized stats and the handle of
+// its creator's mask. Queries using it
+// must alias forks as f and LEFT JOIN fork_stats as stats.
+const forkColumns = `
+	f.id, f.prompt, f.left_label, f.right_label, f.left_asset_id, f.right_asset_id,
+	f.intent_lane, f.mood, f.energy, f.time_fit_s, f.cognitive_load,
+	f.parent_fork_id, f.mutation_type, f.safety_age_gate, f.safety_sensitivity,
+	f.safety_flags, f.created_by_actor_id, f.created_by_mask_id, f.created_at,
+	f.mutation_diff, f.status,
+	(SELECT m.handle FROM masks m WHERE m.id = f.created_by_mask_id) as mask_handle,
+	COALESCE(stats.left_count, 0) as left_count,
+	COALESCE(stats.right_count, 0) as right_count,
+	COALESCE(stats.skip_count, 0) as skip_count,
+	COALESCE(stats.twist_count, 0) as twist_count
+`
+
+// scanFork scans a row selected with forkColumns, followed by any extra
+// destinations for trailing columns
+func scanFork(row pgx.Row, extra ...any) (*domain.Fork, error) {
 	var fork domain.Fork
-	var leftAssetID, rightAssetID, parentForkID, createdByMaskID *uuid.UUID
 	var mood, energy, cognitiveLoad, mutationType *string
 	var timeFitS *int
+	var mutationDiff []byte
+	var maskHandle *string
 
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	dest := []any{
 		&fork.ID,
 		&fork.Prompt,
 		&fork.LeftLabel,
 		&fork.RightLabel,
-		&leftAssetID,
-		&rightAssetID,
+		&fork.LeftAssetID,
+		&fork.RightAssetID,
 		&fork.IntentLane,
 		&mood,
 		&energy,
 		&timeFitS,
 		&cognitiveLoad,
-		&parentForkID,
+		&fork.ParentForkID,
 		&mutationType,
 		&fork.SafetyAgeGate,
 		&fork.SafetySensitivity,
 		&fork.SafetyFlags,
 		&fork.CreatedByActorID,
-		&createdByMaskID,
+		&fork.CreatedByMaskID,
 		&fork.CreatedAt,
+		&mutationDiff,
+		&fork.Status,
+		&maskHandle,
 		&fork.LeftCount,
 		&fork.RightCount,
 		&fork.SkipCount,
 		&fork.TwistCount,
-	)
-	if err != nil {
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return nil, err
 	}
 
-	fork.LeftAssetID = leftAssetID
-	fork.RightAssetID = rightAssetID
-	fork.ParentForkID = parentForkID
-	fork.CreatedByMaskID = createdByMaskID
 	if mood != nil {
 		fork.Mood = *mood
 	}
@@ -129,40 +205,58 @@ func (r *ForkRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.For
 	if timeFitS != nil {
 		fork.TimeFitS = *timeFitS
 	}
+	if maskHandle != nil {
+		fork.MaskHandle = *maskHandle
+	}
+	if mutationDiff != nil {
+		fork.MutationDiff = &domain.MutationDiff{}
+		if err := json.Unmarshal(mutationDiff, fork.MutationDiff); err != nil {
+			return nil, err
+		}
+	}
 
 	return &fork, nil
 }
 
-func (r *ForkRepository) GetFeed(ctx context.Context, lane, energy string, excludeIDs []uuid.UUID, limit int, offset int) ([]*domain.Fork, error) {
+func (r *ForkRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Fork, error) {
 	query := `
-		SELECT
-			f.id, f.prompt, f.left_label, f.right_label, f.left_asset_id, f.right_asset_id,
-			f.intent_lane, f.mood, f.energy, f.time_fit_s, f.cognitive_load,
-			f.parent_fork_id, f.mutation_type, f.safety_age_gate, f.safety_sensitivity,
-			f.safety_flags, f.created_by_actor_id, f.created_by_mask_id, f.created_at,
-			COALESCE(stats.left_count, 0) as left_count,
-			COALESCE(stats.right_count, 0) as right_count,
-			COALESCE(stats.skip_count, 0) as skip_count,
-			COALESCE(stats.twist_count, 0) as twist_count
+		SELECT ` + forkColumns + `
 		FROM forks f
-		LEFT JOIN (
-			SELECT
-				fork_id,
-				COUNT(*) FILTER (WHERE interaction_type = 'swipe_left') as left_count,
-				COUNT(*) FILTER (WHERE interaction_type = 'swipe_right') as right_count,
-				COUNT(*) FILTER (WHERE interaction_type = 'skip') as skip_count,
-				COUNT(*) FILTER (WHERE interaction_type = 'twist') as twist_count
-			FROM interactions
-			GROUP BY fork_id
-		) stats ON f.id = stats.fork_id
-		WHERE ($1 = '' OR f.intent_lane = $1)
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		WHERE f.id = $1
+	`
+	fork, err := scanFork(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return fork, nil
+}
+
+func (r *ForkRepository) GetFeed(ctx context.Context, lane, energy string, excludeIDs []uuid.UUID, after *repository.FeedPosition, limit int) ([]*domain.Fork, error) {
+	query := `
+		SELECT ` + forkColumns + `
+		FROM forks f
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		WHERE f.status = 'visible'
+		  AND ($1 = '' OR f.intent_lane = $1)
 		  AND ($2 = '' OR f.energy = $2)
 		  AND (cardinality($3::uuid[]) = 0 OR f.id != ALL($3::uuid[]))
-		ORDER BY f.created_at DESC
-		LIMIT $4 OFFSET $5
+		  AND ($4::timestamptz IS NULL OR (f.created_at, f.id) < ($4::timestamptz, $5::uuid))
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $6
 	`
 
-	rows, err := r.db.Query(ctx, query, lane, energy, excludeIDs, limit, offset)
+	var afterCreatedAt *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterID = &after.ID
+	}
+
+	rows, err := r.db.Query(ctx, query, lane, energy, excludeIDs, afterCreatedAt, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -170,76 +264,63 @@ func (r *ForkRepository) GetFeed(ctx context.Context, lane, energy string, exclu
 
 	var forks []*domain.Fork
 	for rows.Next() {
-		var fork domain.Fork
-		var leftAssetID, rightAssetID, parentForkID, createdByMaskID *uuid.UUID
-		var mood, energy, cognitiveLoad, mutationType *string
-		var timeFitS *int
-
-		err := rows.Scan(
-			&fork.ID,
-			&fork.Prompt,
-			&fork.LeftLabel,
-			&fork.RightLabel,
-			&leftAssetID,
-			&rightAssetID,
-			&fork.IntentLane,
-			&mood,
-			&energy,
-			&timeFitS,
-			&cognitiveLoad,
-			&parentForkID,
-			&mutationType,
-			&fork.SafetyAgeGate,
-			&fork.SafetySensitivity,
-			&fork.SafetyFlags,
-			&fork.CreatedByActorID,
-			&createdByMaskID,
-			&fork.CreatedAt,
-			&fork.LeftCount,
-			&fork.RightCount,
-			&fork.SkipCount,
-			&fork.TwistCount,
-		)
+		fork, err := scanFork(rows)
 		if err != nil {
 			return nil, err
 		}
+		forks = append(forks, fork)
+	}
 
-		fork.LeftAssetID = leftAssetID
-		fork.RightAssetID = rightAssetID
-		fork.ParentForkID = parentForkID
-		fork.CreatedByMaskID = createdByMaskID
-		if mood != nil {
-			fork.Mood = *mood
-		}
-		if energy != nil {
-			fork.Energy = *energy
-		}
-		if cognitiveLoad != nil {
-			fork.CognitiveLoad = *cognitiveLoad
-		}
-		if mutationType != nil {
-			fork.MutationType = *mutationType
-		}
-		if timeFitS != nil {
-			fork.TimeFitS = *timeFitS
-		}
+	return forks, nil
+}
+
+func (r *ForkRepository) GetVisibleByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Fork, error) {
+	query := `
+		SELECT ` + forkColumns + `
+		FROM forks f
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		WHERE f.id = ANY($1::uuid[])
+		  AND f.status = 'visible'
+		ORDER BY f.created_at DESC, f.id DESC
+	`
 
-		forks = append(forks, &fork)
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forks []*domain.Fork
+	for rows.Next() {
+		fork, err := scanFork(rows)
+		if err != nil {
+			return nil, err
+		}
+		forks = append(forks, fork)
 	}
 
 	return forks, nil
 }
 
-func (r *ForkRepository) GetByParent(ctx context.Context, parentID uuid.UUID) ([]*domain.Fork, error) {
+func (r *ForkRepository) GetByCreator(ctx context.Context, actorID uuid.UUID, after *repository.FeedPosition, limit int) ([]*domain.Fork, error) {
 	query := `
-		SELECT
-			id, prompt, left_label, right_label, intent_lane, mood, energy,
-			mutation_type, created_at
-		FROM forks
-		WHERE parent_fork_id = $1
-		ORDER BY created_at DESC
+		SELECT ` + forkColumns + `
+		FROM forks f
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		WHERE f.created_by_actor_id = $1
+		  AND ($2::timestamptz IS NULL OR (f.created_at, f.id) < ($2::timestamptz, $3::uuid))
+		ORDER BY f.created_at DESC, f.id DESC
+		LIMIT $4
 	`
-	rows, err := r.db.Query(ctx, query, parentID)
+
+	var afterCreatedAt *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterID = &after.ID
+	}
+
+	rows, err := r.db.Query(ctx, query, actorID, afterCreatedAt, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -247,46 +328,118 @@ func (r *ForkRepository) GetByParent(ctx context.Context, parentID uuid.UUID) ([
 
 	var forks []*domain.Fork
 	for rows.Next() {
-		var fork domain.Fork
-		var mood, energy, mutationType *string
-		err := rows.Scan(
-			&fork.ID,
-			&fork.Prompt,
-			&fork.LeftLabel,
-			&fork.RightLabel,
-			&fork.IntentLane,
-			&mood,
-			&energy,
-			&mutationType,
-			&fork.CreatedAt,
-		)
+		fork, err := scanFork(rows)
 		if err != nil {
 			return nil, err
 		}
-		if mood != nil {
-			fork.Mood = *mood
-		}
-		if energy != nil {
-			fork.Energy = *energy
-		}
-		if mutationType != nil {
-			fork.MutationType = *mutationType
+		forks = append(forks, fork)
+	}
+
+	return forks, rows.Err()
+}
+
+func (r *ForkRepository) GetAncestors(ctx context.Context, id uuid.UUID, maxDepth int) ([]*domain.Fork, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_fork_id, 0 AS depth
+			FROM forks
+			WHERE id = $1
+			UNION ALL
+			SELECT p.id, p.parent_fork_id, c.depth + 1
+			FROM forks p
+			JOIN chain c ON p.id = c.parent_fork_id
+			WHERE c.depth < $2
+		)
+		SELECT ` + forkColumns + `
+		FROM chain
+		JOIN forks f ON f.id = chain.id
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		ORDER BY chain.depth DESC
+	`
+	rows, err := r.db.Query(ctx, query, id, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forks []*domain.Fork
+	for rows.Next() {
+		fork, err := scanFork(rows)
+		if err != nil {
+			return nil, err
 		}
-		forks = append(forks, &fork)
+		forks = append(forks, fork)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(forks) == 0 {
+		return nil, domain.ErrNotFound
 	}
 
 	return forks, nil
 }
 
-func (r *ForkRepository) GetReportCount(ctx context.Context, forkID uuid.UUID, since time.Time) (int, error) {
+func (r *ForkRepository) GetDescendants(ctx context.Context, id uuid.UUID, maxDepth int, limit int) ([]*domain.Fork, error) {
 	query := `
-		SELECT COUNT(*)
-		FROM reports
-		WHERE fork_id = $1 AND created_at >= $2 AND state = 'pending'
+		WITH RECURSIVE tree AS (
+			SELECT id, 1 AS depth
+			FROM forks
+			WHERE parent_fork_id = $1
+			UNION ALL
+			SELECT c.id, t.depth + 1
+			FROM forks c
+			JOIN tree t ON c.parent_fork_id = t.id
+			WHERE t.depth < $2
+		)
+		SELECT ` + forkColumns + `
+		FROM tree
+		JOIN forks f ON f.id = tree.id
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		ORDER BY tree.depth ASC, f.created_at ASC, f.id ASC
+		LIMIT $3
 	`
-	var count int
-	err := r.db.QueryRow(ctx, query, forkID, since).Scan(&count)
-	return count, err
+	rows, err := r.db.Query(ctx, query, id, maxDepth, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forks []*domain.Fork
+	for rows.Next() {
+		fork, err := scanFork(rows)
+		if err != nil {
+			return nil, err
+		}
+		forks = append(forks, fork)
+	}
+
+	return forks, rows.Err()
+}
+
+func (r *ForkRepository) GetCreatedTimesByActor(ctx context.Context, actorID uuid.UUID, since time.Time) ([]time.Time, error) {
+	query := `
+		SELECT created_at
+		FROM forks
+		WHERE created_by_actor_id = $1 AND created_at >= $2
+		ORDER BY created_at ASC
+	`
+	rows, err := r.db.Query(ctx, query, actorID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+
+	return times, nil
 }
 
 func (r *ForkRepository) CreateReport(ctx context.Context, report *domain.Report) error {
@@ -302,5 +455,8 @@ func (r *ForkRepository) CreateReport(ctx context.Context, report *domain.Report
 		report.State,
 		report.CreatedAt,
 	)
-	return err
+	if isUniqueViolation(err, "idx_reports_open_actor_fork") {
+		return domain.ErrDuplicateReport
+	}
+	return translateError(err)
 }
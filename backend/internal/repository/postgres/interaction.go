@@ -2,13 +2,18 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var _ repository.InteractionRepository = (*InteractionRepository)(nil)
+
 type InteractionRepository struct {
 	db *pgxpool.Pool
 }
@@ -17,12 +22,123 @@ func NewInteractionRepository(db *pgxpool.Pool) *InteractionRepository {
 	return &InteractionRepository{db: db}
 }
 
+// Create records the interaction and, in the same transaction, updates the
+// actor's vote and the fork's materialized counters.
 func (r *InteractionRepository) Create(ctx context.Context, interaction *domain.Interaction) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockVisibleFork(ctx, tx, interaction.ForkID); err != nil {
+		return err
+	}
+	if err := insertInteraction(ctx, tx, interaction); err != nil {
+		return translateError(err)
+	}
+
+	if domain.IsVoteType(interaction.Type) {
+		previous, err := upsertVote(ctx, tx, interaction)
+		if err != nil {
+			return err
+		}
+		if previous != interaction.Type {
+			if err := incrementForkStats(ctx, tx, interaction.ForkID, interaction.Type, 1); err != nil {
+				return err
+			}
+			if previous != "" {
+				if err := incrementForkStats(ctx, tx, interaction.ForkID, previous, -1); err != nil {
+					return err
+				}
+			}
+		}
+	} else if err := incrementForkStats(ctx, tx, interaction.ForkID, interaction.Type, 1); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *InteractionRepository) GetVote(ctx context.Context, actorID, forkID uuid.UUID) (*domain.Vote, error) {
+	query := `
+		SELECT actor_id, fork_id, vote_type, created_at, updated_at
+		FROM votes
+		WHERE actor_id = $1 AND fork_id = $2
+	`
+	var v domain.Vote
+	err := r.db.QueryRow(ctx, query, actorID, forkID).Scan(&v.ActorID, &v.ForkID, &v.Type, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (r *InteractionRepository) RetractVote(ctx context.Context, actorID, forkID uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockVisibleFork(ctx, tx, forkID); err != nil {
+		return err
+	}
+
+	var previous string
+	err = tx.QueryRow(ctx, `
+		DELETE FROM votes
+		WHERE actor_id = $1 AND fork_id = $2
+		RETURNING vote_type
+	`, actorID, forkID).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		return err
+	}
+
+	if err := insertInteraction(ctx, tx, &domain.Interaction{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		ForkID:    forkID,
+		Type:      domain.InteractionRetract,
+		CreatedAt: at,
+	}); err != nil {
+		return err
+	}
+
+	if err := incrementForkStats(ctx, tx, forkID, previous, -1); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockVisibleFork holds the fork's status for the rest of tx, so moderation
+// can't take it down mid-way, and returns domain.ErrNotFound unless it is
+// visible
+func lockVisibleFork(ctx context.Context, tx pgx.Tx, forkID uuid.UUID) error {
+	var status string
+	err := tx.QueryRow(ctx, `SELECT status FROM forks WHERE id = $1 FOR SHARE`, forkID).Scan(&status)
+	if err != nil {
+		return translateError(err)
+	}
+	if status != domain.ForkStatusVisible {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func insertInteraction(ctx context.Context, tx pgx.Tx, interaction *domain.Interaction) error {
 	query := `
 		INSERT INTO interactions (id, actor_id, fork_id, interaction_type, dwell_ms, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`
-	_, err := r.db.Exec(ctx, query,
+	_, err := tx.Exec(ctx, query,
 		interaction.ID,
 		interaction.ActorID,
 		interaction.ForkID,
@@ -33,6 +149,45 @@ func (r *InteractionRepository) Create(ctx context.Context, interaction *domain.
 	return err
 }
 
+// upsertVote makes the interaction the actor's current vote and returns the
+// vote it replaced ("" if none). The existing row is locked first so two
+// concurrent votes by the same actor can't both count as the first.
+func upsertVote(ctx context.Context, tx pgx.Tx, interaction *domain.Interaction) (string, error) {
+	selectQuery := `
+		SELECT vote_type FROM votes
+		WHERE actor_id = $1 AND fork_id = $2
+		FOR UPDATE
+	`
+	var previous string
+	err := tx.QueryRow(ctx, selectQuery, interaction.ActorID, interaction.ForkID).Scan(&previous)
+	if errors.Is(err, pgx.ErrNoRows) {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO votes (actor_id, fork_id, vote_type, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $4)
+			ON CONFLICT (actor_id, fork_id) DO NOTHING
+		`, interaction.ActorID, interaction.ForkID, interaction.Type, interaction.CreatedAt)
+		if err != nil {
+			return "", err
+		}
+		if tag.RowsAffected() == 1 {
+			return "", nil
+		}
+		// A concurrent request inserted the vote first; lock and replace it
+		err = tx.QueryRow(ctx, selectQuery, interaction.ActorID, interaction.ForkID).Scan(&previous)
+		if err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE votes SET vote_type = $3, updated_at = $4
+		WHERE actor_id = $1 AND fork_id = $2
+	`, interaction.ActorID, interaction.ForkID, interaction.Type, interaction.CreatedAt)
+	return previous, err
+}
+
 func (r *InteractionRepository) GetByActor(ctx context.Context, actorID uuid.UUID, limit int) ([]*domain.Interaction, error) {
 	query := `
 		SELECT id, actor_id, fork_id, interaction_type, dwell_ms, created_at
@@ -64,6 +219,81 @@ func (r *InteractionRepository) GetByActor(ctx context.Context, actorID uuid.UUI
 	return interactions, nil
 }
 
+func (r *InteractionRepository) GetVoteHistory(ctx context.Context, actorID uuid.UUID, after *repository.VotePosition, limit int) ([]*domain.VotedFork, error) {
+	query := `
+		SELECT ` + forkColumns + `, v.actor_id, v.fork_id, v.vote_type, v.created_at, v.updated_at
+		FROM votes v
+		JOIN forks f ON f.id = v.fork_id
+		LEFT JOIN fork_stats stats ON f.id = stats.fork_id
+		WHERE v.actor_id = $1
+		  AND f.status = 'visible'
+		  AND ($2::timestamptz IS NULL OR (v.updated_at, v.fork_id) < ($2::timestamptz, $3::uuid))
+		ORDER BY v.updated_at DESC, v.fork_id DESC
+		LIMIT $4
+	`
+
+	var afterUpdatedAt *time.Time
+	var afterForkID *uuid.UUID
+	if after != nil {
+		afterUpdatedAt = &after.UpdatedAt
+		afterForkID = &after.ForkID
+	}
+
+	rows, err := r.db.Query(ctx, query, actorID, afterUpdatedAt, afterForkID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*domain.VotedFork
+	for rows.Next() {
+		var vote domain.Vote
+		fork, err := scanFork(rows, &vote.ActorID, &vote.ForkID, &vote.Type, &vote.CreatedAt, &vote.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, &domain.VotedFork{Vote: &vote, Fork: fork})
+	}
+
+	return history, rows.Err()
+}
+
+func (r *InteractionRepository) GetActorStats(ctx context.Context, actorID uuid.UUID) (*domain.ActorStats, error) {
+	// Majorities are judged from everyone else's votes, so the actor's own
+	// vote is taken back off the counters first
+	query := `
+		WITH mine AS (
+			SELECT
+				v.vote_type,
+				COALESCE(s.left_count, 0) - CASE WHEN v.vote_type = 'swipe_left' THEN 1 ELSE 0 END as others_left,
+				COALESCE(s.right_count, 0) - CASE WHEN v.vote_type = 'swipe_right' THEN 1 ELSE 0 END as others_right
+			FROM votes v
+			LEFT JOIN fork_stats s ON s.fork_id = v.fork_id
+			WHERE v.actor_id = $1
+		)
+		SELECT
+			(SELECT COUNT(*) FROM mine),
+			(SELECT COUNT(*) FROM mine WHERE vote_type != 'skip' AND others_left != others_right),
+			(SELECT COUNT(*) FROM mine
+			 WHERE (vote_type = 'swipe_left' AND others_left > others_right)
+			    OR (vote_type = 'swipe_right' AND others_right > others_left)),
+			(SELECT COUNT(*) FROM forks WHERE created_by_actor_id = $1 AND parent_fork_id IS NULL),
+			(SELECT COUNT(*) FROM forks WHERE created_by_actor_id = $1 AND parent_fork_id IS NOT NULL)
+	`
+	var stats domain.ActorStats
+	err := r.db.QueryRow(ctx, query, actorID).Scan(
+		&stats.Votes,
+		&stats.ContestedVotes,
+		&stats.MajorityVotes,
+		&stats.ForksCreated,
+		&stats.TwistsCreated,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 func (r *InteractionRepository) GetSeenForkIDs(ctx context.Context, actorID uuid.UUID, since time.Time) ([]uuid.UUID, error) {
 	query := `
 		SELECT DISTINCT fork_id
@@ -88,27 +318,109 @@ func (r *InteractionRepository) GetSeenForkIDs(ctx context.Context, actorID uuid
 	return ids, nil
 }
 
-func (r *InteractionRepository) CountByActorSince(ctx context.Context, actorID uuid.UUID, interactionType string, since time.Time) (int, error) {
+func (r *InteractionRepository) GetForkStats(ctx context.Context, forkID uuid.UUID) (left, right, skip, twist int, err error) {
 	query := `
-		SELECT COUNT(*)
-		FROM interactions
-		WHERE actor_id = $1 AND interaction_type = $2 AND created_at >= $3
+		SELECT left_count, right_count, skip_count, twist_count
+		FROM fork_stats
+		WHERE fork_id = $1
 	`
-	var count int
-	err := r.db.QueryRow(ctx, query, actorID, interactionType, since).Scan(&count)
-	return count, err
+	err = r.db.QueryRow(ctx, query, forkID).Scan(&left, &right, &skip, &twist)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+	return
 }
 
-func (r *InteractionRepository) GetForkStats(ctx context.Context, forkID uuid.UUID) (left, right, skip, twist int, err error) {
+// RebuildForkStats recomputes every fork's counters from the current votes
+// and twist interactions and returns how many forks had drifted. The counter table is locked against
+// concurrent increments for the duration so none are lost to the rebuild.
+func (r *InteractionRepository) RebuildForkStats(ctx context.Context) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE fork_stats IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return 0, err
+	}
+
 	query := `
+		INSERT INTO fork_stats (fork_id, left_count, right_count, skip_count, twist_count, updated_at)
 		SELECT
-			COUNT(*) FILTER (WHERE interaction_type = 'swipe_left') as left_count,
-			COUNT(*) FILTER (WHERE interaction_type = 'swipe_right') as right_count,
-			COUNT(*) FILTER (WHERE interaction_type = 'skip') as skip_count,
-			COUNT(*) FILTER (WHERE interaction_type = 'twist') as twist_count
-		FROM interactions
-		WHERE fork_id = $1
+			f.id,
+			COALESCE(v.left_count, 0),
+			COALESCE(v.right_count, 0),
+			COALESCE(v.skip_count, 0),
+			COALESCE(t.twist_count, 0),
+			NOW()
+		FROM forks f
+		LEFT JOIN (
+			SELECT
+				fork_id,
+				COUNT(*) FILTER (WHERE vote_type = 'swipe_left') as left_count,
+				COUNT(*) FILTER (WHERE vote_type = 'swipe_right') as right_count,
+				COUNT(*) FILTER (WHERE vote_type = 'skip') as skip_count
+			FROM votes
+			GROUP BY fork_id
+		) v ON v.fork_id = f.id
+		LEFT JOIN (
+			-- Twist interactions without a matching twist fork, such as
+			-- those clients could once submit directly, don't count
+			SELECT i.fork_id, SUM(LEAST(i.n, c.n)) as twist_count
+			FROM (
+				SELECT fork_id, actor_id, COUNT(*) as n
+				FROM interactions
+				WHERE interaction_type = 'twist'
+				GROUP BY fork_id, actor_id
+			) i
+			JOIN (
+				SELECT parent_fork_id, created_by_actor_id, COUNT(*) as n
+				FROM forks
+				WHERE parent_fork_id IS NOT NULL
+				GROUP BY parent_fork_id, created_by_actor_id
+			) c ON c.parent_fork_id = i.fork_id AND c.created_by_actor_id = i.actor_id
+			GROUP BY i.fork_id
+		) t ON t.fork_id = f.id
+		ON CONFLICT (fork_id) DO UPDATE SET
+			left_count = EXCLUDED.left_count,
+			right_count = EXCLUDED.right_count,
+			skip_count = EXCLUDED.skip_count,
+			twist_count = EXCLUDED.twist_count,
+			updated_at = EXCLUDED.updated_at
+		WHERE (fork_stats.left_count, fork_stats.right_count, fork_stats.skip_count, fork_stats.twist_count)
+			IS DISTINCT FROM (EXCLUDED.left_count, EXCLUDED.right_count, EXCLUDED.skip_count, EXCLUDED.twist_count)
 	`
-	err = r.db.QueryRow(ctx, query, forkID).Scan(&left, &right, &skip, &twist)
-	return
+	tag, err := tx.Exec(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// incrementForkStats adds delta to the counter matching interactionType
+func incrementForkStats(ctx context.Context, tx pgx.Tx, forkID uuid.UUID, interactionType string, delta int) error {
+	query := `
+		INSERT INTO fork_stats (fork_id, left_count, right_count, skip_count, twist_count, updated_at)
+		VALUES (
+			$1,
+			CASE WHEN $2 = 'swipe_left' THEN $3 ELSE 0 END,
+			CASE WHEN $2 = 'swipe_right' THEN $3 ELSE 0 END,
+			CASE WHEN $2 = 'skip' THEN $3 ELSE 0 END,
+			CASE WHEN $2 = 'twist' THEN $3 ELSE 0 END,
+			NOW()
+		)
+		ON CONFLICT (fork_id) DO UPDATE SET
+			left_count = fork_stats.left_count + EXCLUDED.left_count,
+			right_count = fork_stats.right_count + EXCLUDED.right_count,
+			skip_count = fork_stats.skip_count + EXCLUDED.skip_count,
+			twist_count = fork_stats.twist_count + EXCLUDED.twist_count,
+			updated_at = EXCLUDED.updated_at
+	`
+	_, err := tx.Exec(ctx, query, forkID, interactionType, delta)
+	return err
 }
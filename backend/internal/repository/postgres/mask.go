@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.MaskRepository = (*MaskRepository)(nil)
+
+type MaskRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMaskRepository(db *pgxpool.Pool) *MaskRepository {
+	return &MaskRepository{db: db}
+}
+
+func (r *MaskRepository) GetOrCreateCurrent(ctx context.Context, candidate *domain.Mask, now time.Time) (*domain.Mask, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize mask issuance per actor and lane so concurrent requests
+	// don't each rotate in a new mask
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text || '/' || $2, 0))`,
+		candidate.ActorID, candidate.Lane)
+	if err != nil {
+		return nil, err
+	}
+
+	var mask domain.Mask
+	err = tx.QueryRow(ctx, `
+		SELECT id, actor_id, lane, handle, rotates_at, created_at
+		FROM masks
+		WHERE actor_id = $1 AND lane = $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, candidate.ActorID, candidate.Lane).Scan(
+		&mask.ID,
+		&mask.ActorID,
+		&mask.Lane,
+		&mask.Handle,
+		&mask.RotatesAt,
+		&mask.CreatedAt,
+	)
+	if err == nil && mask.ActiveAt(now) {
+		return &mask, nil
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO masks (id, actor_id, lane, handle, rotates_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		candidate.ID,
+		candidate.ActorID,
+		candidate.Lane,
+		candidate.Handle,
+		candidate.RotatesAt,
+		candidate.CreatedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	created := *candidate
+	return &created, nil
+}
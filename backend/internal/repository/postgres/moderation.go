@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.ModerationRepository = (*ModerationRepository)(nil)
+
+type ModerationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewModerationRepository(db *pgxpool.Pool) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+func (r *ModerationRepository) ListReports(ctx context.Context, state string, after *repository.ReportPosition, limit int) ([]*domain.Report, error) {
+	query := `
+		SELECT id, actor_id, fork_id, reason, state, created_at
+		FROM reports
+		WHERE state = $1
+		  AND ($2::timestamptz IS NULL OR (created_at, id) > ($2::timestamptz, $3::uuid))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $4
+	`
+
+	var afterCreatedAt *time.Time
+	var afterID *uuid.UUID
+	if after != nil {
+		afterCreatedAt = &after.CreatedAt
+		afterID = &after.ID
+	}
+
+	rows, err := r.db.Query(ctx, query, state, afterCreatedAt, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*domain.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *ModerationRepository) GetReport(ctx context.Context, id uuid.UUID) (*domain.Report, error) {
+	query := `
+		SELECT id, actor_id, fork_id, reason, state, created_at
+		FROM reports
+		WHERE id = $1
+	`
+	report, err := scanReport(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func scanReport(row pgx.Row) (*domain.Report, error) {
+	var report domain.Report
+	err := row.Scan(
+		&report.ID,
+		&report.ActorID,
+		&report.ForkID,
+		&report.Reason,
+		&report.State,
+		&report.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *ModerationRepository) OpenReportWeight(ctx context.Context, forkID uuid.UUID) (float64, error) {
+	// Mirrors domain.ReportWeight
+	query := `
+		SELECT COALESCE(SUM(LEAST(GREATEST(a.trust_score, 0), 1)), 0)
+		FROM reports rep
+		JOIN actors a ON a.id = rep.actor_id
+		WHERE rep.fork_id = $1 AND rep.state IN ('pending', 'reviewed')
+	`
+	var weight float64
+	err := r.db.QueryRow(ctx, query, forkID).Scan(&weight)
+	return weight, err
+}
+
+func (r *ModerationRepository) TransitionReport(ctx context.Context, id uuid.UUID, state string, action *domain.ModerationAction, audit *domain.AdminAuditEntry) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	err = tx.QueryRow(ctx, `
+		SELECT state, fork_id FROM reports WHERE id = $1 FOR UPDATE
+	`, id).Scan(&current, &action.ForkID)
+	if err != nil {
+		return translateError(err)
+	}
+	if !domain.ValidReportTransition(current, state) {
+		return domain.ErrInvalidTransition
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE reports SET state = $2 WHERE id = $1`, id, state); err != nil {
+		return translateError(err)
+	}
+	if err := insertModerationAction(ctx, tx, action); err != nil {
+		return err
+	}
+	if err := insertAuditEntry(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *ModerationRepository) TransitionFork(ctx context.Context, forkID uuid.UUID, status, reportState string, action *domain.ModerationAction, audit *domain.AdminAuditEntry) ([]*domain.Report, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	err = tx.QueryRow(ctx, `SELECT status FROM forks WHERE id = $1 FOR UPDATE`, forkID).Scan(&current)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if !domain.ValidForkTransition(current, status) {
+		return nil, domain.ErrInvalidTransition
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE forks SET status = $2 WHERE id = $1`, forkID, status); err != nil {
+		return nil, translateError(err)
+	}
+
+	var settled []*domain.Report
+	if reportState != "" {
+		rows, err := tx.Query(ctx, `
+			UPDATE reports SET state = $2
+			WHERE fork_id = $1 AND state IN ('pending', 'reviewed')
+			RETURNING id, actor_id, fork_id, reason, state, created_at
+		`, forkID, reportState)
+		if err != nil {
+			return nil, translateError(err)
+		}
+		for rows.Next() {
+			report, err := scanReport(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			settled = append(settled, report)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, translateError(err)
+		}
+	}
+	if err := insertModerationAction(ctx, tx, action); err != nil {
+		return nil, err
+	}
+	if err := insertAuditEntry(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return settled, nil
+}
+
+func (r *ModerationRepository) GetReporterStats(ctx context.Context, actorID uuid.UUID) (domain.ReporterStats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE state = 'dismissed'),
+			COUNT(*) FILTER (WHERE state = 'actioned')
+		FROM reports
+		WHERE actor_id = $1
+	`
+	var stats domain.ReporterStats
+	err := r.db.QueryRow(ctx, query, actorID).Scan(&stats.Dismissed, &stats.Actioned)
+	return stats, err
+}
+
+func insertModerationAction(ctx context.Context, tx pgx.Tx, action *domain.ModerationAction) error {
+	query := `
+		INSERT INTO moderation_actions (id, moderator, action, fork_id, report_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := tx.Exec(ctx, query,
+		action.ID,
+		action.Moderator,
+		action.Action,
+		action.ForkID,
+		action.ReportID,
+		action.Note,
+		action.CreatedAt,
+	)
+	return translateError(err)
+}
+
+func (r *ModerationRepository) ListActions(ctx context.Context, forkID uuid.UUID, limit int) ([]*domain.ModerationAction, error) {
+	query := `
+		SELECT id, moderator, action, fork_id, report_id, note, created_at
+		FROM moderation_actions
+		WHERE fork_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, forkID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*domain.ModerationAction
+	for rows.Next() {
+		var action domain.ModerationAction
+		err := rows.Scan(
+			&action.ID,
+			&action.Moderator,
+			&action.Action,
+			&action.ForkID,
+			&action.ReportID,
+			&action.Note,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, &action)
+	}
+
+	return actions, rows.Err()
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.SessionRepository = (*SessionRepository)(nil)
+
+type SessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO auth_sessions (id, actor_id, device_id, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, session.ID, session.ActorID, session.DeviceID, session.CreatedAt)
+	if err != nil {
+		return translateError(err)
+	}
+	if err := insertRefreshToken(ctx, tx, token); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *SessionRepository) Get(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	var session domain.Session
+	err := r.db.QueryRow(ctx, `
+		SELECT id, actor_id, device_id, created_at, revoked_at
+		FROM auth_sessions
+		WHERE id = $1
+	`, id).Scan(
+		&session.ID,
+		&session.ActorID,
+		&session.DeviceID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &session, nil
+}
+
+func insertRefreshToken(ctx context.Context, tx pgx.Tx, token *domain.RefreshToken) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO refresh_tokens (token_hash, session_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, token.Hash, token.SessionID, token.ExpiresAt, token.CreatedAt)
+	return translateError(err)
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, hash string, next *domain.RefreshToken, now time.Time) (*domain.Session, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var session domain.Session
+	var expiresAt time.Time
+	var usedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT s.id, s.actor_id, s.device_id, s.created_at, s.revoked_at, t.expires_at, t.used_at
+		FROM refresh_tokens t
+		JOIN auth_sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, s
+	`, hash).Scan(
+		&session.ID,
+		&session.ActorID,
+		&session.DeviceID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+		&expiresAt,
+		&usedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, domain.ErrSessionRevoked
+	}
+	if usedAt != nil {
+		// Someone is replaying a token that was already exchanged, so the
+		// session can no longer be trusted by either party
+		if _, err := tx.Exec(ctx, `UPDATE auth_sessions SET revoked_at = $2 WHERE id = $1`, session.ID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+		session.RevokedAt = &now
+		return &session, domain.ErrRefreshTokenReused
+	}
+	if !now.Before(expiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = $2 WHERE token_hash = $1`, hash, now); err != nil {
+		return nil, err
+	}
+	next.SessionID = session.ID
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID, now time.Time) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE auth_sessions SET revoked_at = COALESCE(revoked_at, $2)
+		WHERE id = $1
+	`, id, now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *SessionRepository) RevokeByActor(ctx context.Context, actorID uuid.UUID, now time.Time) ([]uuid.UUID, error) {
+	rows, err := r.db.Query(ctx, `
+		UPDATE auth_sessions SET revoked_at = $2
+		WHERE actor_id = $1 AND revoked_at IS NULL
+		RETURNING id
+	`, actorID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *SessionRepository) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRow(ctx, `SELECT revoked_at IS NOT NULL FROM auth_sessions WHERE id = $1`, id).Scan(&revoked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	return revoked, err
+}
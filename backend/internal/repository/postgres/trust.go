@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var _ repository.TrustRepository = (*TrustRepository)(nil)
+
+type TrustRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTrustRepository(db *pgxpool.Pool) *TrustRepository {
+	return &TrustRepository{db: db}
+}
+
+func (r *TrustRepository) GetSignals(ctx context.Context, actorID uuid.UUID, reportsSince, swipesSince time.Time) (*domain.TrustSignals, error) {
+	query := `
+		SELECT
+			a.created_at,
+			received.total, received.upheld,
+			filed.dismissed, filed.actioned,
+			authored.impressions, authored.skips,
+			swipes.recent
+		FROM actors a
+		CROSS JOIN LATERAL (
+			SELECT
+				COUNT(*) FILTER (WHERE r.state <> 'dismissed') AS total,
+				COUNT(*) FILTER (WHERE r.state = 'actioned') AS upheld
+			FROM reports r
+			JOIN forks f ON f.id = r.fork_id
+			WHERE f.created_by_actor_id = a.id AND r.created_at >= $2
+		) received
+		CROSS JOIN LATERAL (
+			SELECT
+				COUNT(*) FILTER (WHERE state = 'dismissed') AS dismissed,
+				COUNT(*) FILTER (WHERE state = 'actioned') AS actioned
+			FROM reports
+			WHERE actor_id = a.id
+		) filed
+		CROSS JOIN LATERAL (
+			SELECT
+				COALESCE(SUM(s.left_count + s.right_count + s.skip_count), 0)::bigint AS impressions,
+				COALESCE(SUM(s.skip_count), 0)::bigint AS skips
+			FROM forks f
+			JOIN fork_stats s ON s.fork_id = f.id
+			WHERE f.created_by_actor_id = a.id
+		) authored
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS recent
+			FROM interactions i
+			WHERE i.actor_id = a.id
+			  AND i.interaction_type IN ('swipe_left', 'swipe_right', 'skip')
+			  AND i.created_at >= $3
+		) swipes
+		WHERE a.id = $1
+	`
+	var signals domain.TrustSignals
+	err := r.db.QueryRow(ctx, query, actorID, reportsSince, swipesSince).Scan(
+		&signals.ActorCreatedAt,
+		&signals.ReportsReceived,
+		&signals.ReportsUpheld,
+		&signals.Reporter.Dismissed,
+		&signals.Reporter.Actioned,
+		&signals.Impressions,
+		&signals.Skips,
+		&signals.RecentSwipes,
+	)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &signals, nil
+}
+
+// trustColumns is the column list scanned by scanTrustScore
+const trustColumns = `actor_id, computed_score, contributions, computed_at, override_score`
+
+func scanTrustScore(row pgx.Row) (*domain.TrustScore, error) {
+	var score domain.TrustScore
+	var computed *float64
+	var contributions []byte
+	var computedAt *time.Time
+	err := row.Scan(
+		&score.ActorID,
+		&computed,
+		&contributions,
+		&computedAt,
+		&score.Override,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if computed != nil {
+		score.Computed = *computed
+	}
+	if computedAt != nil {
+		score.ComputedAt = *computedAt
+	}
+	if err := json.Unmarshal(contributions, &score.Contributions); err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+func (r *TrustRepository) Get(ctx context.Context, actorID uuid.UUID) (*domain.TrustScore, error) {
+	query := `SELECT ` + trustColumns + ` FROM actor_trust WHERE actor_id = $1`
+	score, err := scanTrustScore(r.db.QueryRow(ctx, query, actorID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return score, nil
+}
+
+func (r *TrustRepository) Save(ctx context.Context, score *domain.TrustScore) (*domain.TrustScore, error) {
+	contributions, err := json.Marshal(score.Contributions)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single statement, so the actor can't be left out of step with the
+	// stored score
+	query := `
+		WITH saved AS (
+			INSERT INTO actor_trust (actor_id, computed_score, contributions, computed_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (actor_id) DO UPDATE SET
+				computed_score = EXCLUDED.computed_score,
+				contributions = EXCLUDED.contributions,
+				computed_at = EXCLUDED.computed_at
+			RETURNING ` + trustColumns + `
+		), applied AS (
+			UPDATE actors a
+			SET trust_score = COALESCE(saved.override_score, saved.computed_score)
+			FROM saved
+			WHERE a.id = saved.actor_id
+		)
+		SELECT ` + trustColumns + ` FROM saved
+	`
+	saved, err := scanTrustScore(r.db.QueryRow(ctx, query,
+		score.ActorID,
+		score.Computed,
+		contributions,
+		score.ComputedAt,
+	))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return saved, nil
+}
+
+func (r *TrustRepository) SetOverride(ctx context.Context, actorID uuid.UUID, override *float64, audit *domain.AdminAuditEntry) (*domain.TrustScore, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Clearing an override before the actor was ever scored leaves their
+	// trust_score alone until the next computation
+	query := `
+		WITH saved AS (
+			INSERT INTO actor_trust (actor_id, override_score)
+			VALUES ($1, $2)
+			ON CONFLICT (actor_id) DO UPDATE SET override_score = EXCLUDED.override_score
+			RETURNING ` + trustColumns + `
+		), applied AS (
+			UPDATE actors a
+			SET trust_score = COALESCE(saved.override_score, saved.computed_score, a.trust_score)
+			FROM saved
+			WHERE a.id = saved.actor_id
+		)
+		SELECT ` + trustColumns + ` FROM saved
+	`
+	saved, err := scanTrustScore(tx.QueryRow(ctx, query, actorID, override))
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if err := insertAuditEntry(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (r *TrustRepository) ListStale(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	query := `
+		SELECT a.id
+		FROM actors a
+		LEFT JOIN actor_trust t ON t.actor_id = a.id
+		WHERE t.computed_at IS NULL OR t.computed_at < $1
+		ORDER BY t.computed_at ASC NULLS FIRST, a.id ASC
+		LIMIT $2
+	`
+	rows, err := r.db.Query(ctx, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,54 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ repository.ActorCacheStore = (*ActorCacheStore)(nil)
+
+type ActorCacheStore struct {
+	redis *redis.Client
+}
+
+func NewActorCacheStore(redis *redis.Client) *ActorCacheStore {
+	return &ActorCacheStore{redis: redis}
+}
+
+func (s *ActorCacheStore) Get(ctx context.Context, actorID uuid.UUID) (*domain.Actor, error) {
+	data, err := s.redis.Get(ctx, actorCacheKey(actorID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var actor domain.Actor
+	if err := json.Unmarshal(data, &actor); err != nil {
+		return nil, err
+	}
+	return &actor, nil
+}
+
+func (s *ActorCacheStore) Set(ctx context.Context, actor *domain.Actor, ttl time.Duration) error {
+	data, err := json.Marshal(actor)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, actorCacheKey(actor.ID), data, ttl).Err()
+}
+
+func (s *ActorCacheStore) Delete(ctx context.Context, actorID uuid.UUID) error {
+	return s.redis.Del(ctx, actorCacheKey(actorID)).Err()
+}
+
+func actorCacheKey(actorID uuid.UUID) string {
+	return "actor:" + actorID.String()
+}
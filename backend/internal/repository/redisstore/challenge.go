@@ -0,0 +1,39 @@
+// Package redisstore implements the short-lived stores of the repository
+// package on Redis, so they are shared by every API server.
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ repository.ChallengeStore = (*ChallengeStore)(nil)
+
+type ChallengeStore struct {
+	redis *redis.Client
+}
+
+func NewChallengeStore(redis *redis.Client) *ChallengeStore {
+	return &ChallengeStore{redis: redis}
+}
+
+func (s *ChallengeStore) Put(ctx context.Context, nonce string, ttl time.Duration) error {
+	return s.redis.Set(ctx, challengeKey(nonce), 1, ttl).Err()
+}
+
+// Consume deletes the key; DEL is atomic, so of concurrent calls exactly one
+// sees it removed
+func (s *ChallengeStore) Consume(ctx context.Context, nonce string) (bool, error) {
+	n, err := s.redis.Del(ctx, challengeKey(nonce)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func challengeKey(nonce string) string {
+	return "device_challenge:" + nonce
+}
@@ -0,0 +1,92 @@
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ repository.FeedSessionStore = (*FeedSessionStore)(nil)
+
+// FeedSessionStore keeps the session intent as JSON under one key and the
+// history as a list of JSON switches, most recent first, under another
+type FeedSessionStore struct {
+	redis *redis.Client
+}
+
+func NewFeedSessionStore(redis *redis.Client) *FeedSessionStore {
+	return &FeedSessionStore{redis: redis}
+}
+
+func (s *FeedSessionStore) Get(ctx context.Context, actorID uuid.UUID) (*domain.Intent, error) {
+	data, err := s.redis.Get(ctx, sessionKey(actorID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var intent domain.Intent
+	if err := json.Unmarshal(data, &intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (s *FeedSessionStore) Set(ctx context.Context, actorID uuid.UUID, intent domain.Intent, ttl time.Duration) error {
+	data, err := json.Marshal(intent)
+	if err != nil {
+		return err
+	}
+	return s.redis.Set(ctx, sessionKey(actorID), data, ttl).Err()
+}
+
+func (s *FeedSessionStore) AddSwitch(ctx context.Context, actorID uuid.UUID, sw domain.IntentSwitch, keep int, ttl time.Duration) error {
+	entry, err := json.Marshal(sw)
+	if err != nil {
+		return err
+	}
+	key := sessionHistoryKey(actorID)
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, entry)
+		pipe.LTrim(ctx, key, 0, int64(keep)-1)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+// Switches skips entries that don't parse rather than failing the feed
+func (s *FeedSessionStore) Switches(ctx context.Context, actorID uuid.UUID, limit int) ([]domain.IntentSwitch, error) {
+	entries, err := s.redis.LRange(ctx, sessionHistoryKey(actorID), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	switches := make([]domain.IntentSwitch, 0, len(entries))
+	for _, entry := range entries {
+		var sw domain.IntentSwitch
+		if err := json.Unmarshal([]byte(entry), &sw); err != nil {
+			continue
+		}
+		switches = append(switches, sw)
+	}
+	return switches, nil
+}
+
+func (s *FeedSessionStore) Delete(ctx context.Context, actorID uuid.UUID) error {
+	return s.redis.Del(ctx, sessionKey(actorID), sessionHistoryKey(actorID)).Err()
+}
+
+func sessionKey(actorID uuid.UUID) string {
+	return "session:" + actorID.String()
+}
+
+func sessionHistoryKey(actorID uuid.UUID) string {
+	return "session_history:" + actorID.String()
+}
@@ -0,0 +1,41 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ repository.LinkCodeStore = (*LinkCodeStore)(nil)
+
+type LinkCodeStore struct {
+	redis *redis.Client
+}
+
+func NewLinkCodeStore(redis *redis.Client) *LinkCodeStore {
+	return &LinkCodeStore{redis: redis}
+}
+
+func (s *LinkCodeStore) Put(ctx context.Context, code string, actorID uuid.UUID, ttl time.Duration) (bool, error) {
+	return s.redis.SetNX(ctx, linkCodeKey(code), actorID.String(), ttl).Result()
+}
+
+func (s *LinkCodeStore) Redeem(ctx context.Context, code string) (uuid.UUID, error) {
+	value, err := s.redis.GetDel(ctx, linkCodeKey(code)).Result()
+	if errors.Is(err, redis.Nil) {
+		return uuid.Nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(value)
+}
+
+func linkCodeKey(code string) string {
+	return "device_link:" + code
+}
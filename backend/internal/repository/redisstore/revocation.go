@@ -0,0 +1,36 @@
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ repository.RevocationStore = (*RevocationStore)(nil)
+
+type RevocationStore struct {
+	redis *redis.Client
+}
+
+func NewRevocationStore(redis *redis.Client) *RevocationStore {
+	return &RevocationStore{redis: redis}
+}
+
+func (s *RevocationStore) Revoke(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error {
+	return s.redis.Set(ctx, revokedSessionKey(sessionID), 1, ttl).Err()
+}
+
+func (s *RevocationStore) IsRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	n, err := s.redis.Exists(ctx, revokedSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func revokedSessionKey(sessionID uuid.UUID) string {
+	return "revoked_session:" + sessionID.String()
+}
@@ -0,0 +1,339 @@
+// Package repository defines the storage contracts used by the service layer.
+// Implementations live in the postgres and memory subpackages.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ActorRepository persists actors. Lookups return domain.ErrNotFound when no
+// actor matches.
+type ActorRepository interface {
+	Create(ctx context.Context, actor *domain.Actor) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Actor, error)
+	// GetByDeviceFingerprint returns the most recently created actor that
+	// reported the fingerprint. Fingerprints are only hints and may repeat.
+	GetByDeviceFingerprint(ctx context.Context, fingerprint string) (*domain.Actor, error)
+	// UpdateStatus sets the actor's status and appends audit, when non-nil,
+	// to the admin audit log in the same transaction. Returns
+	// domain.ErrNotFound if the actor does not exist.
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string, audit *domain.AdminAuditEntry) error
+}
+
+// DeviceRepository persists the devices actors sign in from and the
+// recovery codes that let them link new ones. Recovery codes are stored and
+// looked up by hash.
+type DeviceRepository interface {
+	// GetByKeyID returns the device registered with the public key, or
+	// domain.ErrNotFound
+	GetByKeyID(ctx context.Context, keyID string) (*domain.Device, error)
+	// ListByActor returns the actor's devices, most recently seen first
+	ListByActor(ctx context.Context, actorID uuid.UUID) ([]*domain.Device, error)
+	// Register stores the device for its actor. When newActor is non-nil it
+	// is created in the same transaction with the given recovery code and
+	// becomes the device's actor.
+	Register(ctx context.Context, device *domain.Device, newActor *domain.Actor, recoveryCodeHash string) error
+	// ClaimLegacyActor assigns the device to the actor whose refresh token
+	// has the given hash, provided the actor has no devices, i.e. signed up
+	// before devices were registered. The token proves ownership: it is
+	// spent and its session revoked with the claim, and the actor is given
+	// the recovery code. Returns the actor and the revoked session. Errors
+	// are those of SessionRepository.Rotate, including the revocation of a
+	// reused token's session, and domain.ErrAlreadyClaimed if the actor
+	// already has a device.
+	ClaimLegacyActor(ctx context.Context, device *domain.Device, refreshTokenHash, recoveryCodeHash string, now time.Time) (*domain.Actor, *domain.Session, error)
+	// Recover registers the device to the actor holding the recovery code
+	// and replaces the code with next, atomically, returning the actor.
+	// Returns domain.ErrInvalidRecoveryCode if no actor holds the code.
+	Recover(ctx context.Context, device *domain.Device, recoveryCodeHash, nextRecoveryCodeHash string) (*domain.Actor, error)
+	// SetRecoveryCode gives the actor a new recovery code, replacing any
+	// previous one
+	SetRecoveryCode(ctx context.Context, actorID uuid.UUID, recoveryCodeHash string, now time.Time) error
+	// Touch records a sign-in from the device reporting fingerprint
+	Touch(ctx context.Context, id uuid.UUID, fingerprint string, now time.Time) error
+	// Delete unlinks one of the actor's devices, deleting its sessions, and
+	// returns the IDs of the sessions that were still open. Returns
+	// domain.ErrNotFound if the actor has no such device and
+	// domain.ErrLastDevice if it is their only one.
+	Delete(ctx context.Context, actorID, id uuid.UUID) ([]uuid.UUID, error)
+}
+
+// SessionRepository persists sign-in sessions and their refresh tokens
+type SessionRepository interface {
+	// Create stores a new session with its first refresh token
+	Create(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error
+	// Get returns the session, revoked or not, or domain.ErrNotFound
+	Get(ctx context.Context, id uuid.UUID) (*domain.Session, error)
+	// Rotate exchanges the refresh token with the given hash for next, which
+	// joins the same session, and returns the session. Returns
+	// domain.ErrInvalidRefreshToken if the token is unknown or expired and
+	// domain.ErrSessionRevoked if its session was revoked. A token that was
+	// already exchanged revokes its session and returns
+	// domain.ErrRefreshTokenReused along with the session.
+	Rotate(ctx context.Context, hash string, next *domain.RefreshToken, now time.Time) (*domain.Session, error)
+	// Revoke revokes the session unless it already was. Returns
+	// domain.ErrNotFound if it does not exist.
+	Revoke(ctx context.Context, id uuid.UUID, now time.Time) error
+	// RevokeByActor revokes all of the actor's open sessions and returns
+	// their IDs
+	RevokeByActor(ctx context.Context, actorID uuid.UUID, now time.Time) ([]uuid.UUID, error)
+	// IsRevoked reports whether the session was revoked. Unknown sessions
+	// count as revoked.
+	IsRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// AccountRepository reads and erases everything stored about an actor
+type AccountRepository interface {
+	// Export gathers the actor's data from a single consistent snapshot.
+	// Returns domain.ErrNotFound if the actor does not exist.
+	Export(ctx context.Context, actorID uuid.UUID) (*domain.AccountData, error)
+	// Delete erases the actor with their devices, sessions, masks, votes,
+	// interactions, reports and export jobs in one transaction, taking their
+	// votes and twists off the counters of forks that remain. Deletion jobs
+	// are kept so they can still be polled. Their forks are deleted or
+	// handed to domain.DeletedActorID as documented there. Returns
+	// domain.ErrNotFound if the actor does not exist.
+	Delete(ctx context.Context, actorID uuid.UUID) error
+}
+
+// AccountJobRepository queues account export and deletion jobs
+type AccountJobRepository interface {
+	Create(ctx context.Context, job *domain.AccountJob) error
+	// Get returns the job or domain.ErrNotFound
+	Get(ctx context.Context, id uuid.UUID) (*domain.AccountJob, error)
+	// GetLatest returns the actor's most recent job of the kind, or
+	// domain.ErrNotFound
+	GetLatest(ctx context.Context, actorID uuid.UUID, kind string) (*domain.AccountJob, error)
+	// Claim marks the oldest pending job, or the oldest running job started
+	// before staleBefore, as running at now and returns it. Concurrent
+	// claims never return the same job. Returns domain.ErrNotFound if there
+	// is nothing to do.
+	Claim(ctx context.Context, now, staleBefore time.Time) (*domain.AccountJob, error)
+	// Finish records the job's final status and, for exports, its archive
+	Finish(ctx context.Context, id uuid.UUID, status string, archive []byte, now time.Time) error
+	// DeleteFinishedBefore deletes jobs that finished before the given time
+	// and returns how many there were
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// MaskRepository persists the masks actors create content under
+type MaskRepository interface {
+	// GetOrCreateCurrent returns the actor's newest mask in the candidate's
+	// lane if it is still active at now, and otherwise stores the candidate
+	// and returns it. Concurrent calls for the same actor and lane agree on
+	// a single mask.
+	GetOrCreateCurrent(ctx context.Context, candidate *domain.Mask, now time.Time) (*domain.Mask, error)
+}
+
+// FeedPosition is a keyset pagination position in the feed ordering
+type FeedPosition struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ForkRepository persists forks and the reports filed against them. Reads
+// return forks with their materialized interaction stats populated.
+type ForkRepository interface {
+	// Create inserts the fork unless its creator already created limit
+	// forks since the given time, counted with the creator locked so
+	// concurrent creations can't both fit in the last slot. For a twist it
+	// also records a twist interaction by the creator on the parent and
+	// bumps the parent's twist counter, atomically with the insert. Returns
+	// domain.ErrRateLimited if the limit is reached, and
+	// domain.ErrParentUnavailable if the parent is missing or not twistable
+	// at that moment.
+	Create(ctx context.Context, fork *domain.Fork, since time.Time, limit int) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Fork, error)
+	// GetFeed returns forks ordered by (created_at, id) descending, starting
+	// strictly after the given position when it is non-nil.
+	GetFeed(ctx context.Context, lane, energy string, excludeIDs []uuid.UUID, after *FeedPosition, limit int) ([]*domain.Fork, error)
+	// GetVisibleByIDs returns the visible forks among ids, ordered like
+	// GetFeed. Missing or hidden forks are left out.
+	GetVisibleByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Fork, error)
+	// GetByCreator returns the actor's forks in any status, ordered and
+	// paginated like GetFeed
+	GetByCreator(ctx context.Context, actorID uuid.UUID, after *FeedPosition, limit int) ([]*domain.Fork, error)
+	// GetAncestors returns the chain from the root fork down to and including
+	// id, following at most maxDepth parent links. Returns domain.ErrNotFound
+	// if id does not exist.
+	GetAncestors(ctx context.Context, id uuid.UUID, maxDepth int) ([]*domain.Fork, error)
+	// GetDescendants returns up to limit forks descending from id, at most
+	// maxDepth levels down, shallowest levels first.
+	GetDescendants(ctx context.Context, id uuid.UUID, maxDepth int, limit int) ([]*domain.Fork, error)
+	// GetCreatedTimesByActor returns when the actor created each fork since
+	// the given time, oldest first.
+	GetCreatedTimesByActor(ctx context.Context, actorID uuid.UUID, since time.Time) ([]time.Time, error)
+	// CreateReport files a report. Returns domain.ErrDuplicateReport if the
+	// actor already has an open report on the fork.
+	CreateReport(ctx context.Context, report *domain.Report) error
+}
+
+// ReportPosition is a keyset pagination position in the moderation queue
+// ordering
+type ReportPosition struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ModerationRepository backs the moderation queue. Every change it makes is
+// recorded in the moderation audit log in the same transaction, and so is
+// the admin audit entry passed with a change made through the admin API.
+type ModerationRepository interface {
+	// ListReports returns reports in the given state ordered by
+	// (created_at, id) ascending, starting strictly after the given position
+	// when it is non-nil.
+	ListReports(ctx context.Context, state string, after *ReportPosition, limit int) ([]*domain.Report, error)
+	GetReport(ctx context.Context, id uuid.UUID) (*domain.Report, error)
+	// OpenReportWeight sums domain.ReportWeight over the fork's open reports
+	// using each reporter's current trust score.
+	OpenReportWeight(ctx context.Context, forkID uuid.UUID) (float64, error)
+	// TransitionReport moves the report to state and records action, whose
+	// ForkID is filled in from the report, and audit when non-nil. Returns
+	// domain.ErrNotFound or domain.ErrInvalidTransition.
+	TransitionReport(ctx context.Context, id uuid.UUID, state string, action *domain.ModerationAction, audit *domain.AdminAuditEntry) error
+	// TransitionFork moves the fork to status, settles its open reports to
+	// reportState unless it is empty, and records action, and audit when
+	// non-nil. Returns the settled reports, or domain.ErrNotFound or
+	// domain.ErrInvalidTransition.
+	TransitionFork(ctx context.Context, forkID uuid.UUID, status, reportState string, action *domain.ModerationAction, audit *domain.AdminAuditEntry) ([]*domain.Report, error)
+	// GetReporterStats counts how the actor's decided reports were resolved
+	GetReporterStats(ctx context.Context, actorID uuid.UUID) (domain.ReporterStats, error)
+	// ListActions returns the audit log for a fork, newest first
+	ListActions(ctx context.Context, forkID uuid.UUID, limit int) ([]*domain.ModerationAction, error)
+}
+
+// AuditPosition is a keyset pagination position in the admin audit log
+// ordering
+type AuditPosition struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// AdminAuditRepository reads the append-only admin audit log. Entries are
+// appended by the repositories making the audited changes, in the same
+// transaction, so no change goes unrecorded.
+type AdminAuditRepository interface {
+	// List returns entries ordered by (created_at, id) descending, starting
+	// strictly before the given position when it is non-nil. A non-nil
+	// targetID restricts the results to that target.
+	List(ctx context.Context, targetID *uuid.UUID, before *AuditPosition, limit int) ([]*domain.AdminAuditEntry, error)
+}
+
+// TrustRepository gathers trust signals and stores the scores computed from
+// them. Saving a score or an override also updates the actor's trust_score
+// to the effective value, in the same transaction.
+type TrustRepository interface {
+	// GetSignals gathers the actor's trust signals, counting reports against
+	// their forks from reportsSince and their swipes from swipesSince.
+	// Returns domain.ErrNotFound if the actor does not exist.
+	GetSignals(ctx context.Context, actorID uuid.UUID, reportsSince, swipesSince time.Time) (*domain.TrustSignals, error)
+	// Get returns the actor's latest score, or domain.ErrNotFound if they
+	// have never been scored or overridden
+	Get(ctx context.Context, actorID uuid.UUID) (*domain.TrustScore, error)
+	// Save records a computed score, keeping any override, and returns the
+	// stored score
+	Save(ctx context.Context, score *domain.TrustScore) (*domain.TrustScore, error)
+	// SetOverride pins the actor's trust score, or unpins it when override
+	// is nil, appends audit to the admin audit log when non-nil, and returns
+	// the stored score. Returns domain.ErrNotFound if the actor does not
+	// exist.
+	SetOverride(ctx context.Context, actorID uuid.UUID, override *float64, audit *domain.AdminAuditEntry) (*domain.TrustScore, error)
+	// ListStale returns up to limit actors never scored or last scored
+	// before the given time, least recently scored first
+	ListStale(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+}
+
+// VotePosition is a keyset pagination position in an actor's vote history
+type VotePosition struct {
+	UpdatedAt time.Time
+	ForkID    uuid.UUID
+}
+
+// InteractionRepository persists actor interactions with forks and keeps the
+// per-fork counters in step with them.
+type InteractionRepository interface {
+	// Create appends the interaction to the history. Vote types also become
+	// the actor's current vote on the fork, replacing any previous vote, and
+	// the counters move from the old vote to the new one. Returns
+	// domain.ErrNotFound unless the fork is visible.
+	Create(ctx context.Context, interaction *domain.Interaction) error
+	// GetVote returns the actor's current vote or domain.ErrNotFound
+	GetVote(ctx context.Context, actorID, forkID uuid.UUID) (*domain.Vote, error)
+	// RetractVote removes the actor's current vote, records a retract
+	// interaction and decrements the counter. Returns domain.ErrNotFound if
+	// there was no vote or the fork is not visible.
+	RetractVote(ctx context.Context, actorID, forkID uuid.UUID, at time.Time) error
+	GetByActor(ctx context.Context, actorID uuid.UUID, limit int) ([]*domain.Interaction, error)
+	// GetVoteHistory returns the actor's current votes on visible forks with
+	// the forks attached, ordered by (updated_at, fork_id) descending and
+	// starting strictly after the given position when it is non-nil
+	GetVoteHistory(ctx context.Context, actorID uuid.UUID, after *VotePosition, limit int) ([]*domain.VotedFork, error)
+	// GetActorStats counts the actor's votes and forks. Majorities are
+	// judged from the current counters.
+	GetActorStats(ctx context.Context, actorID uuid.UUID) (*domain.ActorStats, error)
+	GetSeenForkIDs(ctx context.Context, actorID uuid.UUID, since time.Time) ([]uuid.UUID, error)
+	GetForkStats(ctx context.Context, forkID uuid.UUID) (left, right, skip, twist int, err error)
+	// RebuildForkStats recomputes the materialized per-fork counters from the
+	// current votes and twist interactions and returns the number of forks
+	// that were corrected. Only twist interactions matched by a twist the
+	// actor created count.
+	RebuildForkStats(ctx context.Context) (int, error)
+}
+
+// The stores below hold short-lived state that expires on its own. Redis
+// backs them in production; the memory package implements them for running
+// without external services.
+
+// ChallengeStore keeps the nonces devices sign to sign in until they are
+// used or expire
+type ChallengeStore interface {
+	Put(ctx context.Context, nonce string, ttl time.Duration) error
+	// Consume deletes the nonce and reports whether it was there. Of
+	// concurrent calls for the same nonce exactly one reports true.
+	Consume(ctx context.Context, nonce string) (bool, error)
+}
+
+// LinkCodeStore keeps device link codes until they are redeemed or expire
+type LinkCodeStore interface {
+	// Put stores the code for the actor unless the code is already in use,
+	// and reports whether it was stored
+	Put(ctx context.Context, code string, actorID uuid.UUID, ttl time.Duration) (bool, error)
+	// Redeem deletes the code and returns the actor it was issued to, or
+	// domain.ErrNotFound if there is no such code
+	Redeem(ctx context.Context, code string) (uuid.UUID, error)
+}
+
+// RevocationStore lists revoked sessions for as long as their access tokens
+// could still be valid
+type RevocationStore interface {
+	Revoke(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error
+	IsRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// ActorCacheStore keeps recently resolved actors for a short while
+type ActorCacheStore interface {
+	// Get returns the cached actor or domain.ErrNotFound
+	Get(ctx context.Context, actorID uuid.UUID) (*domain.Actor, error)
+	Set(ctx context.Context, actor *domain.Actor, ttl time.Duration) error
+	Delete(ctx context.Context, actorID uuid.UUID) error
+}
+
+// FeedSessionStore keeps actors' feed sessions and their history of intent
+// switches
+type FeedSessionStore interface {
+	// Get returns the actor's session intent or domain.ErrNotFound
+	Get(ctx context.Context, actorID uuid.UUID) (*domain.Intent, error)
+	Set(ctx context.Context, actorID uuid.UUID, intent domain.Intent, ttl time.Duration) error
+	// AddSwitch records a switch at the front of the actor's history,
+	// keeping the keep most recent, and keeps the history for ttl
+	AddSwitch(ctx context.Context, actorID uuid.UUID, sw domain.IntentSwitch, keep int, ttl time.Duration) error
+	// Switches returns up to limit of the actor's switches, most recent
+	// first
+	Switches(ctx context.Context, actorID uuid.UUID, limit int) ([]domain.IntentSwitch, error)
+	// Delete forgets the actor's session and history
+	Delete(ctx context.Context, actorID uuid.UUID) error
+}
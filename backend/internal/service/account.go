@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// SessionStore holds the feed session actors last chose
+type SessionStore interface {
+	ClearSession(ctx context.Context, actorID uuid.UUID) error
+}
+
+// AccountService exports and deletes actors' accounts on request. Both run
+// as queued jobs, processed by Run, which the actor polls for the outcome.
+type AccountService struct {
+	accountRepo repository.AccountRepository
+	jobRepo     repository.AccountJobRepository
+	sessions    SessionRevoker
+	actorCache  ActorCache
+	feed        SessionStore
+
+	wake chan struct{}
+}
+
+func NewAccountService(
+	accountRepo repository.AccountRepository,
+	jobRepo repository.AccountJobRepository,
+	sessions SessionRevoker,
+	actorCache ActorCache,
+	feed SessionStore,
+) *AccountService {
+	return &AccountService{
+		accountRepo: accountRepo,
+		jobRepo:     jobRepo,
+		sessions:    sessions,
+		actorCache:  actorCache,
+		feed:        feed,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// RequestExport queues an export of the actor's data. An export already in
+// progress is returned instead of queueing another.
+func (s *AccountService) RequestExport(ctx context.Context, actorID uuid.UUID) (*domain.AccountJob, error) {
+	return s.request(ctx, actorID, domain.AccountJobExport)
+}
+
+// RequestDeletion queues the deletion of the actor's account. A deletion
+// already in progress is returned instead of queueing another.
+func (s *AccountService) RequestDeletion(ctx context.Context, actorID uuid.UUID) (*domain.AccountJob, error) {
+	return s.request(ctx, actorID, domain.AccountJobDelete)
+}
+
+func (s *AccountService) request(ctx context.Context, actorID uuid.UUID, kind string) (*domain.AccountJob, error) {
+	latest, err := s.jobRepo.GetLatest(ctx, actorID, kind)
+	if err == nil && !latest.Finished() {
+		return latest, nil
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	job := domain.NewAccountJob(actorID, kind)
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// GetExport returns the actor's latest export job, with the archive once it
+// has completed. Returns domain.ErrNotFound if they have none.
+func (s *AccountService) GetExport(ctx context.Context, actorID uuid.UUID) (*domain.AccountJob, error) {
+	return s.jobRepo.GetLatest(ctx, actorID, domain.AccountJobExport)
+}
+
+// GetJob returns a job by ID, which is all it takes to poll one: a deletion
+// job has to be pollable after its actor, and their credentials, are gone
+func (s *AccountService) GetJob(ctx context.Context, id uuid.UUID) (*domain.AccountJob, error) {
+	return s.jobRepo.Get(ctx, id)
+}
+
+// Run processes queued jobs as they are requested, and every interval picks
+// up jobs abandoned by a crashed worker and drops expired ones, until ctx is
+// done
+func (s *AccountService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Pick up whatever was queued before a restart
+	s.processPending(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.processPending(ctx)
+		case <-ticker.C:
+			s.processPending(ctx)
+			deleted, err := s.jobRepo.DeleteFinishedBefore(ctx, time.Now().Add(-domain.AccountJobRetention))
+			if err != nil {
+				log.Printf("Account job cleanup failed: %v", err)
+			}
+			if deleted > 0 {
+				log.Printf("Account job cleanup deleted %d jobs", deleted)
+			}
+		}
+	}
+}
+
+func (s *AccountService) processPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := s.jobRepo.Claim(ctx, now, now.Add(-domain.AccountJobTimeout))
+		if errors.Is(err, domain.ErrNotFound) {
+			return
+		}
+		if err != nil {
+			log.Printf("Claiming account job failed: %v", err)
+			return
+		}
+		s.process(ctx, job)
+	}
+}
+
+// process carries out a claimed job and records its outcome
+func (s *AccountService) process(ctx context.Context, job *domain.AccountJob) {
+	var archive []byte
+	var err error
+	switch job.Kind {
+	case domain.AccountJobExport:
+		archive, err = s.export(ctx, job.ActorID)
+	case domain.AccountJobDelete:
+		err = s.deleteAccount(ctx, job.ActorID)
+	default:
+		err = errors.New("unknown job kind " + job.Kind)
+	}
+
+	status := domain.AccountJobCompleted
+	if err != nil {
+		log.Printf("Account %s job %s for actor %s failed: %v", job.Kind, job.ID, job.ActorID, err)
+		status = domain.AccountJobFailed
+	}
+	if err := s.jobRepo.Finish(ctx, job.ID, status, archive, time.Now()); err != nil {
+		// The job is picked up again once it times out
+		log.Printf("Recording account job %s failed: %v", job.ID, err)
+	}
+}
+
+func (s *AccountService) export(ctx context.Context, actorID uuid.UUID) ([]byte, error) {
+	data, err := s.accountRepo.Export(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(domain.NewAccountArchive(data, time.Now()))
+}
+
+// deleteAccount signs the actor out everywhere, erases them and drops their
+// cached actor and feed session. An actor already gone counts as deleted, so
+// a retried job completes.
+func (s *AccountService) deleteAccount(ctx context.Context, actorID uuid.UUID) error {
+	// Revoke first so access tokens stop working at once; the sessions
+	// themselves are deleted with the actor
+	if err := s.sessions.RevokeActorSessions(ctx, actorID); err != nil {
+		return err
+	}
+	if err := s.accountRepo.Delete(ctx, actorID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	// Both expire on their own, so failures only leave them around longer
+	if err := s.actorCache.InvalidateActor(ctx, actorID); err != nil {
+		log.Printf("Failed to invalidate cached actor %s: %v", actorID, err)
+	}
+	if err := s.feed.ClearSession(ctx, actorID); err != nil {
+		log.Printf("Failed to clear feed session of actor %s: %v", actorID, err)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Admin listing bounds
+const (
+	AdminListDefaultLimit = 50
+	AdminListMaxLimit     = 100
+)
+
+// ActorCache drops cached actor records so admin changes take effect on the
+// actor's next request
+type ActorCache interface {
+	InvalidateActor(ctx context.Context, actorID uuid.UUID) error
+}
+
+// SessionRevoker signs actors out of all their sessions
+type SessionRevoker interface {
+	RevokeActorSessions(ctx context.Context, actorID uuid.UUID) error
+}
+
+// AdminService carries out staff actions from the admin API. Every change is
+// appended to the admin audit log under the acting staff member's ID, in the
+// same transaction as the change itself.
+type AdminService struct {
+	actorRepo  repository.ActorRepository
+	auditRepo  repository.AdminAuditRepository
+	moderation *ModerationService
+	trust      *TrustService
+	actorCache ActorCache
+	sessions   SessionRevoker
+}
+
+func NewAdminService(
+	actorRepo repository.ActorRepository,
+	auditRepo repository.AdminAuditRepository,
+	moderation *ModerationService,
+	trust *TrustService,
+	actorCache ActorCache,
+	sessions SessionRevoker,
+) *AdminService {
+	return &AdminService{
+		actorRepo:  actorRepo,
+		auditRepo:  auditRepo,
+		moderation: moderation,
+		trust:      trust,
+		actorCache: actorCache,
+		sessions:   sessions,
+	}
+}
+
+// ActorReview is what staff see about an actor. Trust is nil until the
+// actor has been scored.
+type ActorReview struct {
+	Actor   *domain.Actor
+	Reports domain.ReporterStats
+	Trust   *domain.TrustScore
+}
+
+// GetActor looks an actor up by ID
+func (s *AdminService) GetActor(ctx context.Context, id uuid.UUID) (*ActorReview, error) {
+	actor, err := s.actorRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.reviewActor(ctx, actor)
+}
+
+// FindActor looks an actor up by device fingerprint
+func (s *AdminService) FindActor(ctx context.Context, fingerprint string) (*ActorReview, error) {
+	actor, err := s.actorRepo.GetByDeviceFingerprint(ctx, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return s.reviewActor(ctx, actor)
+}
+
+func (s *AdminService) reviewActor(ctx context.Context, actor *domain.Actor) (*ActorReview, error) {
+	stats, err := s.moderation.GetReporterStats(ctx, actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	trust, err := s.trust.Get(ctx, actor.ID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+	return &ActorReview{Actor: actor, Reports: stats, Trust: trust}, nil
+}
+
+// SetActorStatus suspends, bans or reinstates an actor
+func (s *AdminService) SetActorStatus(ctx context.Context, staff *domain.Staff, id uuid.UUID, status, reason string) (*domain.Actor, error) {
+	if !domain.ValidActorStatus(status) {
+		return nil, &domain.FieldError{Field: "status", Err: domain.ErrInvalidInput}
+	}
+	if reason == "" {
+		return nil, &domain.FieldError{Field: "reason", Err: domain.ErrMissingRequired}
+	}
+
+	actor, err := s.actorRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := actor.Status
+
+	audit := newAuditEntry(staff, domain.AdminActionSetActorStatus, domain.AuditTargetActor, id, map[string]any{
+		"from":   previous,
+		"to":     status,
+		"reason": reason,
+	})
+	if err := s.actorRepo.UpdateStatus(ctx, id, status, audit); err != nil {
+		return nil, err
+	}
+	actor.Status = status
+	s.invalidateActor(ctx, id)
+
+	// Suspended and banned actors lose their refresh tokens too, so they
+	// can't sign back in once their access tokens expire
+	if status != domain.ActorStatusActive {
+		if err := s.sessions.RevokeActorSessions(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return actor, nil
+}
+
+// SetTrustScore pins an actor's trust score, overriding the computed one,
+// or hands it back to the trust engine when score is nil
+func (s *AdminService) SetTrustScore(ctx context.Context, staff *domain.Staff, id uuid.UUID, score *float64, reason string) (*domain.Actor, error) {
+	if score != nil && !domain.ValidTrustScore(*score) {
+		return nil, &domain.FieldError{Field: "trust_score", Err: domain.ErrInvalidInput}
+	}
+	if reason == "" {
+		return nil, &domain.FieldError{Field: "reason", Err: domain.ErrMissingRequired}
+	}
+
+	actor, err := s.actorRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := actor.TrustScore
+
+	// Unpinning hands the score back to the trust engine, which recomputes
+	// it after the change is recorded, so only the override is known here
+	audit := newAuditEntry(staff, domain.AdminActionSetTrustScore, domain.AuditTargetActor, id, map[string]any{
+		"from":     previous,
+		"override": score,
+		"reason":   reason,
+	})
+	trust, err := s.trust.SetOverride(ctx, id, score, audit)
+	if err != nil {
+		return nil, err
+	}
+	actor.TrustScore = trust.Effective()
+	return actor, nil
+}
+
+func (s *AdminService) invalidateActor(ctx context.Context, id uuid.UUID) {
+	// The cached record expires on its own shortly, so this isn't fatal
+	if err := s.actorCache.InvalidateActor(ctx, id); err != nil {
+		log.Printf("Failed to invalidate cached actor %s: %v", id, err)
+	}
+}
+
+// ListReports returns a page of the report queue
+func (s *AdminService) ListReports(ctx context.Context, state, cursor string, limit int) ([]*domain.Report, string, error) {
+	return s.moderation.ListReports(ctx, state, cursor, limit)
+}
+
+// ResolveReport moves a report to state
+func (s *AdminService) ResolveReport(ctx context.Context, staff *domain.Staff, id uuid.UUID, state, note string) (*domain.Report, error) {
+	// A report never moves to another fork, so its fork can be read ahead
+	// of the change
+	report, err := s.moderation.GetReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAuditEntry(staff, domain.AdminActionResolveReport, domain.AuditTargetReport, id, map[string]any{
+		"fork_id": report.ForkID.String(),
+		"state":   state,
+		"note":    note,
+	})
+	return s.moderation.ResolveReport(ctx, staff.ID, id, state, note, audit)
+}
+
+// GetForkReview loads a fork in any status with its moderation history
+func (s *AdminService) GetForkReview(ctx context.Context, id uuid.UUID) (*ForkReview, error) {
+	return s.moderation.GetForkReview(ctx, id)
+}
+
+// RemoveFork takes a fork down
+func (s *AdminService) RemoveFork(ctx context.Context, staff *domain.Staff, id uuid.UUID, reason string) error {
+	if reason == "" {
+		return &domain.FieldError{Field: "reason", Err: domain.ErrMissingRequired}
+	}
+	audit := newAuditEntry(staff, domain.AdminActionRemoveFork, domain.AuditTargetFork, id, map[string]any{
+		"reason": reason,
+	})
+	return s.moderation.RemoveFork(ctx, staff.ID, id, reason, audit)
+}
+
+// RestoreFork makes a hidden or removed fork visible again
+func (s *AdminService) RestoreFork(ctx context.Context, staff *domain.Staff, id uuid.UUID, reason string) error {
+	audit := newAuditEntry(staff, domain.AdminActionRestoreFork, domain.AuditTargetFork, id, map[string]any{
+		"reason": reason,
+	})
+	return s.moderation.RestoreFork(ctx, staff.ID, id, reason, audit)
+}
+
+// ListAudit returns a page of the audit log, newest first, optionally for a
+// single target
+func (s *AdminService) ListAudit(ctx context.Context, targetID *uuid.UUID, cursor string, limit int) ([]*domain.AdminAuditEntry, string, error) {
+	var before *repository.AuditPosition
+	if cursor != "" {
+		createdAt, id, err := decodeKeyset(cursor)
+		if err != nil {
+			return nil, "", &domain.FieldError{Field: "cursor", Err: err}
+		}
+		before = &repository.AuditPosition{CreatedAt: createdAt, ID: id}
+	}
+
+	entries, err := s.auditRepo.List(ctx, targetID, before, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(entries) == limit {
+		last := entries[len(entries)-1]
+		next = encodeKeyset(last.CreatedAt, last.ID)
+	}
+	return entries, next, nil
+}
+
+// newAuditEntry builds the audit log entry for a change. It is handed to the
+// repository making the change so both are written in one transaction.
+func newAuditEntry(staff *domain.Staff, action, targetType string, targetID uuid.UUID, details map[string]any) *domain.AdminAuditEntry {
+	return &domain.AdminAuditEntry{
+		ID:         uuid.New(),
+		StaffID:    staff.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -2,81 +2,438 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
 	"time"
 
+	"github.com/forkfall/backend/internal/attestation"
 	"github.com/forkfall/backend/internal/domain"
-	"github.com/forkfall/backend/internal/repository/postgres"
+	"github.com/forkfall/backend/internal/jwtkeys"
+	"github.com/forkfall/backend/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 )
 
+// How long a resolved actor is cached before status changes are picked up
+const actorCacheTTL = 30 * time.Second
+
 type AuthService struct {
-	actorRepo *postgres.ActorRepository
-	jwtSecret []byte
+	actorRepo    repository.ActorRepository
+	deviceRepo   repository.DeviceRepository
+	sessionRepo  repository.SessionRepository
+	challenges   repository.ChallengeStore
+	linkCodes    repository.LinkCodeStore
+	revocations  repository.RevocationStore
+	actorCache   repository.ActorCacheStore
+	keys         *jwtkeys.Manager
+	attestations *attestation.Registry
 }
 
-func NewAuthService(actorRepo *postgres.ActorRepository, jwtSecret string) *AuthService {
+func NewAuthService(
+	actorRepo repository.ActorRepository,
+	deviceRepo repository.DeviceRepository,
+	sessionRepo repository.SessionRepository,
+	challenges repository.ChallengeStore,
+	linkCodes repository.LinkCodeStore,
+	revocations repository.RevocationStore,
+	actorCache repository.ActorCacheStore,
+	keys *jwtkeys.Manager,
+	attestations *attestation.Registry,
+) *AuthService {
 	return &AuthService{
-		actorRepo: actorRepo,
-		jwtSecret: []byte(jwtSecret),
+		actorRepo:    actorRepo,
+		deviceRepo:   deviceRepo,
+		sessionRepo:  sessionRepo,
+		challenges:   challenges,
+		linkCodes:    linkCodes,
+		revocations:  revocations,
+		actorCache:   actorCache,
+		keys:         keys,
+		attestations: attestations,
 	}
 }
 
+// AuthResult is a freshly issued token pair. Token is the access token, valid
+// for ExpiresIn; RefreshToken exchanges for the next pair exactly once.
+// RecoveryCode is set when the actor was issued a new recovery code, which
+// is shown only this once.
 type AuthResult struct {
-	Token   string
-	ActorID uuid.UUID
-	IsNew   bool
+	Token        string
+	RefreshToken string
+	ExpiresIn    time.Duration
+	ActorID      uuid.UUID
+	IsNew        bool
+	RecoveryCode string
+}
+
+// DeviceChallenge is a single-use nonce a device signs to sign in
+type DeviceChallenge struct {
+	Nonce     string
+	ExpiresAt time.Time
+}
+
+// NewChallenge issues a nonce for AuthenticateDevice, valid for
+// domain.DeviceChallengeTTL
+func (s *AuthService) NewChallenge(ctx context.Context) (*DeviceChallenge, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(secret)
+
+	if err := s.challenges.Put(ctx, nonce, domain.DeviceChallengeTTL); err != nil {
+		return nil, err
+	}
+	return &DeviceChallenge{
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(domain.DeviceChallengeTTL),
+	}, nil
+}
+
+// consumeChallenge redeems a nonce issued by NewChallenge. Of concurrent
+// redemptions exactly one succeeds.
+func (s *AuthService) consumeChallenge(ctx context.Context, nonce string) error {
+	ok, err := s.challenges.Consume(ctx, nonce)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrInvalidChallenge
+	}
+	return nil
+}
+
+// DeviceAuthInput answers a challenge. Signature is the device key's
+// signature over the challenge nonce. Attestation is optional unless the
+// attestation registry requires it, and is only checked when a key is first
+// registered. A new key can be linked to an existing actor with either a
+// link code or the actor's recovery code, or take over an actor that signed
+// up before device keys existed with a refresh token of theirs.
+type DeviceAuthInput struct {
+	Fingerprint        string
+	PublicKey          []byte
+	Challenge          string
+	Signature          []byte
+	AttestationFormat  string
+	Attestation        []byte
+	LinkCode           string
+	RecoveryCode       string
+	LegacyRefreshToken string
 }
 
-func (s *AuthService) AuthenticateDevice(ctx context.Context, deviceFingerprint string) (*AuthResult, error) {
-	// Try to find existing actor
-	actor, err := s.actorRepo.GetByDeviceFingerprint(ctx, deviceFingerprint)
-	isNew := false
+// AuthenticateDevice signs a device in by its keypair. A known key signs in
+// to the actor it is linked to. A new key is linked to the actor named by
+// its link code, recovery code or legacy refresh token, or else to a fresh
+// actor. The fingerprint never selects an actor.
+func (s *AuthService) AuthenticateDevice(ctx context.Context, input DeviceAuthInput) (*AuthResult, error) {
+	given := 0
+	for _, code := range []string{input.LinkCode, input.RecoveryCode, input.LegacyRefreshToken} {
+		if code != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, &domain.FieldError{Field: "recovery_code", Err: domain.ErrInvalidInput}
+	}
+	if err := s.consumeChallenge(ctx, input.Challenge); err != nil {
+		return nil, err
+	}
 
+	device, err := domain.NewDevice(input.PublicKey, input.Fingerprint)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			// Create new actor
-			actor = domain.NewActor(deviceFingerprint)
-			if err := s.actorRepo.Create(ctx, actor); err != nil {
-				return nil, err
-			}
-			isNew = true
-		} else {
+		return nil, err
+	}
+	if err := device.VerifySignature([]byte(input.Challenge), input.Signature); err != nil {
+		return nil, err
+	}
+
+	var reg *deviceRegistration
+	existing, err := s.deviceRepo.GetByKeyID(ctx, device.KeyID)
+	switch {
+	case err == nil:
+		// Codes are only for linking new keys; leave them unredeemed
+		if given > 0 {
+			return nil, domain.ErrDeviceAlreadyRegistered
+		}
+		device = existing
+		if err := s.deviceRepo.Touch(ctx, device.ID, input.Fingerprint, time.Now()); err != nil {
+			return nil, err
+		}
+		actor, err := s.actorRepo.GetByID(ctx, device.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		reg = &deviceRegistration{actor: actor}
+	case errors.Is(err, domain.ErrNotFound):
+		reg, err = s.registerDevice(ctx, device, input)
+		if err != nil {
 			return nil, err
 		}
+	default:
+		return nil, err
 	}
 
 	// Check if actor is active
-	if !actor.IsActive() {
-		return nil, domain.ErrUnauthorized
+	if err := reg.actor.CheckActive(); err != nil {
+		return nil, err
 	}
 
-	// Generate JWT
-	token, err := s.generateToken(actor.ID)
+	result, err := s.startSession(ctx, reg.actor.ID, &device.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.IsNew = reg.isNew
+	result.RecoveryCode = reg.recoveryCode
+	return result, nil
+}
+
+// deviceRegistration is the outcome of linking a new device key. recoveryCode
+// is set if the actor was given a new one.
+type deviceRegistration struct {
+	actor        *domain.Actor
+	isNew        bool
+	recoveryCode string
+}
+
+// registerDevice links a new device key: to the actor named by the link
+// code, recovery code or legacy refresh token if one was given, and to a new
+// actor otherwise
+func (s *AuthService) registerDevice(ctx context.Context, device *domain.Device, input DeviceAuthInput) (*deviceRegistration, error) {
+	err := s.attestations.Check(ctx, input.AttestationFormat, input.PublicKey, []byte(input.Challenge), input.Attestation)
+	if err != nil {
+		return nil, err
+	}
+	device.AttestationFormat = input.AttestationFormat
+
+	if input.LinkCode != "" {
+		actorID, err := s.redeemLinkCode(ctx, input.LinkCode)
+		if err != nil {
+			return nil, err
+		}
+		device.ActorID = actorID
+		if err := s.deviceRepo.Register(ctx, device, nil, ""); err != nil {
+			return nil, err
+		}
+		actor, err := s.actorRepo.GetByID(ctx, actorID)
+		if err != nil {
+			return nil, err
+		}
+		return &deviceRegistration{actor: actor}, nil
+	}
+
+	// Every other path hands out a fresh recovery code
+	recoveryCode, err := newRecoveryCode()
+	if err != nil {
+		return nil, err
+	}
+	recoveryHash := hashCode(recoveryCode)
+
+	if input.RecoveryCode != "" {
+		actor, err := s.deviceRepo.Recover(ctx, device, hashCode(input.RecoveryCode), recoveryHash)
+		if err != nil {
+			return nil, err
+		}
+		return &deviceRegistration{actor: actor, recoveryCode: recoveryCode}, nil
+	}
+
+	if input.LegacyRefreshToken != "" {
+		// The legacy session is spent by the claim either way
+		actor, session, err := s.deviceRepo.ClaimLegacyActor(ctx, device, hashRefreshToken(input.LegacyRefreshToken), recoveryHash, time.Now())
+		if session != nil {
+			s.markRevoked(ctx, session.ID)
+		}
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			log.Printf("Refresh token reused in legacy claim of session %s of actor %s; session revoked", session.ID, session.ActorID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &deviceRegistration{actor: actor, recoveryCode: recoveryCode}, nil
+	}
+
+	actor := domain.NewActor(input.Fingerprint)
+	if err := s.deviceRepo.Register(ctx, device, actor, recoveryHash); err != nil {
+		return nil, err
+	}
+	return &deviceRegistration{actor: actor, isNew: true, recoveryCode: recoveryCode}, nil
+}
+
+// startSession starts a session with its first refresh token
+func (s *AuthService) startSession(ctx context.Context, actorID uuid.UUID, deviceID *uuid.UUID) (*AuthResult, error) {
+	now := time.Now()
+	session := &domain.Session{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		DeviceID:  deviceID,
+		CreatedAt: now,
+	}
+	refreshToken, record, err := newRefreshToken(session.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.Create(ctx, session, record); err != nil {
+		return nil, err
+	}
+
+	token, err := s.generateToken(actorID, session.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    domain.AccessTokenTTL,
+		ActorID:      actorID,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair in the same
+// session. Presenting a refresh token a second time revokes the session,
+// since either the client or an attacker holds a stolen copy.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, error) {
+	now := time.Now()
+	next, record, err := newRefreshToken(uuid.Nil, now)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.Rotate(ctx, hashRefreshToken(refreshToken), record, now)
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		log.Printf("Refresh token reused in session %s of actor %s; session revoked", session.ID, session.ActorID)
+		s.markRevoked(ctx, session.ID)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := s.actorRepo.GetByID(ctx, session.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := actor.CheckActive(); err != nil {
+		return nil, err
+	}
+
+	token, err := s.generateToken(actor.ID, session.ID, now)
 	if err != nil {
 		return nil, err
 	}
 
 	return &AuthResult{
-		Token:   token,
-		ActorID: actor.ID,
-		IsNew:   isNew,
+		Token:        token,
+		RefreshToken: next,
+		ExpiresIn:    domain.AccessTokenTTL,
+		ActorID:      actor.ID,
 	}, nil
 }
 
-func (s *AuthService) generateToken(actorID uuid.UUID) (string, error) {
+// Logout revokes a session, invalidating its refresh token and, through the
+// revocation list, its outstanding access tokens
+func (s *AuthService) Logout(ctx context.Context, sessionID uuid.UUID) error {
+	if err := s.sessionRepo.Revoke(ctx, sessionID, time.Now()); err != nil {
+		return err
+	}
+	s.markRevoked(ctx, sessionID)
+	return nil
+}
+
+// RevokeActorSessions signs the actor out of every session
+func (s *AuthService) RevokeActorSessions(ctx context.Context, actorID uuid.UUID) error {
+	ids, err := s.sessionRepo.RevokeByActor(ctx, actorID, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s.markRevoked(ctx, id)
+	}
+	return nil
+}
+
+// IsSessionRevoked reports whether access tokens of the session must be
+// rejected. Revoked sessions are listed in the revocation store for as long
+// as their access tokens could still be valid; if the store is unavailable
+// the database is asked instead.
+func (s *AuthService) IsSessionRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	revoked, err := s.revocations.IsRevoked(ctx, sessionID)
+	if err == nil {
+		return revoked, nil
+	}
+	return s.sessionRepo.IsRevoked(ctx, sessionID)
+}
+
+// markRevoked adds the session to the revocation list. The session is
+// already revoked in the database, so a failure only delays the rejection
+// of its access tokens until they expire.
+func (s *AuthService) markRevoked(ctx context.Context, sessionID uuid.UUID) {
+	if err := s.revocations.Revoke(ctx, sessionID, domain.AccessTokenTTL); err != nil {
+		log.Printf("Failed to list revoked session %s: %v", sessionID, err)
+	}
+}
+
+// generateToken issues an access token. sid ties it to its session for
+// revocation and jti makes every token unique.
+func (s *AuthService) generateToken(actorID, sessionID uuid.UUID, now time.Time) (string, error) {
 	claims := jwt.MapClaims{
 		"actor_id": actorID.String(),
-		"exp":      time.Now().Add(30 * 24 * time.Hour).Unix(), // 30 days
-		"iat":      time.Now().Unix(),
+		"sid":      sessionID.String(),
+		"jti":      uuid.New().String(),
+		"exp":      now.Add(domain.AccessTokenTTL).Unix(),
+		"iat":      now.Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	return s.keys.Sign(claims)
+}
+
+// newRefreshToken generates an opaque refresh token and the record stored
+// for it, which holds only its hash
+func newRefreshToken(sessionID uuid.UUID, now time.Time) (string, *domain.RefreshToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	return token, &domain.RefreshToken{
+		Hash:      hashRefreshToken(token),
+		SessionID: sessionID,
+		ExpiresAt: now.Add(domain.RefreshTokenTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func (s *AuthService) GetActor(ctx context.Context, actorID uuid.UUID) (*domain.Actor, error) {
 	return s.actorRepo.GetByID(ctx, actorID)
 }
+
+// ResolveActor loads the actor behind an authenticated request, serving from
+// a short-lived cache so every request doesn't hit the database. Cache
+// failures fall through to the repository.
+func (s *AuthService) ResolveActor(ctx context.Context, actorID uuid.UUID) (*domain.Actor, error) {
+	if actor, err := s.actorCache.Get(ctx, actorID); err == nil {
+		return actor, nil
+	}
+
+	actor, err := s.actorRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.actorCache.Set(ctx, actor, actorCacheTTL)
+	return actor, nil
+}
+
+// InvalidateActor drops the cached actor so a status or trust change takes
+// effect on the next request
+func (s *AuthService) InvalidateActor(ctx context.Context, actorID uuid.UUID) error {
+	return s.actorCache.Delete(ctx, actorID)
+}
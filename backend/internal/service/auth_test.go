@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/forkfall/backend/internal/attestation"
+	"github.com/forkfall/backend/internal/clock"
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/jwtkeys"
+	"github.com/forkfall/backend/internal/repository/memory"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newTestAuthService(t *testing.T) (*AuthService, *jwtkeys.Manager) {
+	t.Helper()
+	keys, err := jwtkeys.NewManager([]*jwtkeys.Key{jwtkeys.NewHMACKey("test", []byte("secret"))}, domain.AccessTokenTTL, clock.Real{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.NewStore()
+	auth := NewAuthService(
+		memory.NewActorRepository(store),
+		memory.NewDeviceRepository(store),
+		memory.NewSessionRepository(store),
+		memory.NewChallengeStore(store),
+		memory.NewLinkCodeStore(store),
+		memory.NewRevocationStore(store),
+		memory.NewActorCacheStore(store),
+		keys,
+		attestation.NewRegistry(false),
+	)
+	return auth, keys
+}
+
+// testDevice is a device keypair that answers challenges like the app does
+type testDevice struct {
+	publicKey  []byte
+	privateKey ed25519.PrivateKey
+}
+
+func newTestDevice(t *testing.T) *testDevice {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testDevice{publicKey: der, privateKey: priv}
+}
+
+func (d *testDevice) answer(t *testing.T, auth *AuthService) DeviceAuthInput {
+	t.Helper()
+	challenge, err := auth.NewChallenge(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return DeviceAuthInput{
+		Fingerprint: "test-device",
+		PublicKey:   d.publicKey,
+		Challenge:   challenge.Nonce,
+		Signature:   ed25519.Sign(d.privateKey, []byte(challenge.Nonce)),
+	}
+}
+
+func sessionOf(t *testing.T, keys *jwtkeys.Manager, token string) uuid.UUID {
+	t.Helper()
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, keys.Keyfunc, jwt.WithValidMethods(keys.Algorithms())); err != nil {
+		t.Fatal(err)
+	}
+	sid, _ := claims["sid"].(string)
+	id, err := uuid.Parse(sid)
+	if err != nil {
+		t.Fatalf("sid claim %q: %v", sid, err)
+	}
+	return id
+}
+
+func TestAuthenticateDeviceSignsInByKey(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := newTestAuthService(t)
+	device := newTestDevice(t)
+
+	input := device.answer(t, auth)
+	first, err := auth.AuthenticateDevice(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.IsNew || first.RecoveryCode == "" {
+		t.Errorf("first sign-in: IsNew %v, recovery code %q; want a new actor with a recovery code", first.IsNew, first.RecoveryCode)
+	}
+
+	// Each challenge is good for one sign-in
+	if _, err := auth.AuthenticateDevice(ctx, input); !errors.Is(err, domain.ErrInvalidChallenge) {
+		t.Errorf("replayed challenge: err %v, want %v", err, domain.ErrInvalidChallenge)
+	}
+
+	// A signature by another key doesn't pass for this one
+	forged := device.answer(t, auth)
+	forged.Signature = ed25519.Sign(newTestDevice(t).privateKey, []byte(forged.Challenge))
+	if _, err := auth.AuthenticateDevice(ctx, forged); err == nil {
+		t.Error("expected a signature by another key to be refused")
+	}
+
+	again, err := auth.AuthenticateDevice(ctx, device.answer(t, auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.IsNew || again.ActorID != first.ActorID {
+		t.Errorf("second sign-in: actor %s (new %v), want %s", again.ActorID, again.IsNew, first.ActorID)
+	}
+}
+
+func TestAuthenticateDeviceLinksByCode(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := newTestAuthService(t)
+
+	first, err := auth.AuthenticateDevice(ctx, newTestDevice(t).answer(t, auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := auth.NewLinkCode(ctx, first.ActorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := newTestDevice(t).answer(t, auth)
+	input.LinkCode = code.Code
+	linked, err := auth.AuthenticateDevice(ctx, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.ActorID != first.ActorID {
+		t.Errorf("linked device signed in as %s, want %s", linked.ActorID, first.ActorID)
+	}
+
+	// The code is spent
+	input = newTestDevice(t).answer(t, auth)
+	input.LinkCode = code.Code
+	if _, err := auth.AuthenticateDevice(ctx, input); !errors.Is(err, domain.ErrInvalidLinkCode) {
+		t.Errorf("reused link code: err %v, want %v", err, domain.ErrInvalidLinkCode)
+	}
+}
+
+func TestRefreshRotatesAndRevokesOnReuse(t *testing.T) {
+	ctx := context.Background()
+	auth, keys := newTestAuthService(t)
+
+	signedIn, err := auth.AuthenticateDevice(ctx, newTestDevice(t).answer(t, auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID := sessionOf(t, keys, signedIn.Token)
+
+	refreshed, err := auth.Refresh(ctx, signedIn.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.RefreshToken == signedIn.RefreshToken {
+		t.Error("refresh handed back the same refresh token")
+	}
+	if got := sessionOf(t, keys, refreshed.Token); got != sessionID {
+		t.Errorf("refreshed token is for session %s, want %s", got, sessionID)
+	}
+
+	// Presenting the spent token again revokes the whole session
+	if _, err := auth.Refresh(ctx, signedIn.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenReused) {
+		t.Fatalf("reused refresh token: err %v, want %v", err, domain.ErrRefreshTokenReused)
+	}
+	revoked, err := auth.IsSessionRevoked(ctx, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("session still valid after refresh token reuse")
+	}
+	if _, err := auth.Refresh(ctx, refreshed.RefreshToken); err == nil {
+		t.Error("expected the rotated refresh token to die with its session")
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// feedCursor is the payload of an opaque feed page token. It pins the keyset
+// position of the end of the candidate window fetched so far, the candidates
+// from that window not returned yet, and the ranking snapshot the pages were
+// scored with, so later pages are ranked consistently with earlier ones.
+type feedCursor struct {
+	CreatedAt int64     `json:"t"` // unix microseconds, Postgres timestamp precision
+	ID        uuid.UUID `json:"id"`
+	Pending   []byte    `json:"p,omitempty"` // concatenated 16-byte fork IDs
+	Lane      string    `json:"l,omitempty"`
+	Energy    string    `json:"e,omitempty"`
+	Mood      string    `json:"m,omitempty"`
+	AsOf      int64     `json:"a"` // unix seconds used as "now" when scoring
+}
+
+func (c feedCursor) position() *repository.FeedPosition {
+	return &repository.FeedPosition{
+		CreatedAt: time.UnixMicro(c.CreatedAt),
+		ID:        c.ID,
+	}
+}
+
+func (c feedCursor) pendingIDs() ([]uuid.UUID, error) {
+	if len(c.Pending)%16 != 0 {
+		return nil, domain.ErrInvalidInput
+	}
+	ids := make([]uuid.UUID, len(c.Pending)/16)
+	for i := range ids {
+		copy(ids[i][:], c.Pending[i*16:])
+	}
+	return ids, nil
+}
+
+func packIDs(forks []*domain.Fork) []byte {
+	packed := make([]byte, 0, len(forks)*16)
+	for _, fork := range forks {
+		packed = append(packed, fork.ID[:]...)
+	}
+	return packed
+}
+
+// cursorCodec signs and verifies feed cursors with HMAC-SHA256
+type cursorCodec struct {
+	key []byte
+}
+
+func (c cursorCodec) encode(cursor feedCursor) string {
+	payload, _ := json.Marshal(cursor)
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body))
+}
+
+// decode verifies and parses a cursor; any tampering or malformed input
+// yields domain.ErrInvalidInput.
+func (c cursorCodec) decode(token string) (*feedCursor, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, domain.ErrInvalidInput
+	}
+
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, c.sign(body)) {
+		return nil, domain.ErrInvalidInput
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	var cursor feedCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, domain.ErrInvalidInput
+	}
+	return &cursor, nil
+}
+
+func (c cursorCodec) sign(body string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// Keyset cursors for staff listings and an actor's own forks and votes are
+// "<timestamp unix micros>.<id>". They only page through data the caller may
+// already read in full, so unlike feed cursors they aren't signed.
+func encodeKeyset(createdAt time.Time, id uuid.UUID) string {
+	return strconv.FormatInt(createdAt.UnixMicro(), 10) + "." + id.String()
+}
+
+func decodeKeyset(cursor string) (time.Time, uuid.UUID, error) {
+	micros, idStr, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return time.Time{}, uuid.Nil, domain.ErrInvalidInput
+	}
+	t, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, domain.ErrInvalidInput
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.Nil, domain.ErrInvalidInput
+	}
+	return time.UnixMicro(t), id, nil
+}
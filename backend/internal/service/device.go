@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/google/uuid"
+)
+
+// LinkedDevice is one of an actor's devices. Current marks the device the
+// request was made from.
+type LinkedDevice struct {
+	*domain.Device
+	Current bool
+}
+
+// ListDevices returns the actor's linked devices, most recently seen first
+func (s *AuthService) ListDevices(ctx context.Context, actorID, sessionID uuid.UUID) ([]LinkedDevice, error) {
+	session, err := s.sessionRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := s.deviceRepo.ListByActor(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := make([]LinkedDevice, len(devices))
+	for i, device := range devices {
+		linked[i] = LinkedDevice{
+			Device:  device,
+			Current: session.DeviceID != nil && *session.DeviceID == device.ID,
+		}
+	}
+	return linked, nil
+}
+
+// UnlinkDevice removes one of the actor's devices and signs it out. The
+// actor's only device can't be unlinked.
+func (s *AuthService) UnlinkDevice(ctx context.Context, actorID, deviceID uuid.UUID) error {
+	sessionIDs, err := s.deviceRepo.Delete(ctx, actorID, deviceID)
+	if err != nil {
+		return err
+	}
+	for _, id := range sessionIDs {
+		s.markRevoked(ctx, id)
+	}
+	return nil
+}
+
+// LinkCode is a short single-use code that links a new device to the actor
+// who requested it
+type LinkCode struct {
+	Code      string
+	ExpiresAt time.Time
+}
+
+// Link code and recovery code shapes, in characters of codeAlphabet
+const (
+	linkCodeLength      = 8
+	recoveryCodeLength  = 20
+	codeGroupLength     = 4
+	recoveryGroupLength = 5
+)
+
+// codeAlphabet omits characters that are easily confused when copied by hand
+const codeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// NewLinkCode issues a code that links the next new device presenting it
+// to the actor, valid for domain.DeviceLinkCodeTTL
+func (s *AuthService) NewLinkCode(ctx context.Context, actorID uuid.UUID) (*LinkCode, error) {
+	for {
+		code, err := newCode(linkCodeLength, codeGroupLength)
+		if err != nil {
+			return nil, err
+		}
+		// Never take over a code another actor is about to use
+		ok, err := s.linkCodes.Put(ctx, normalizeCode(code), actorID, domain.DeviceLinkCodeTTL)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &LinkCode{
+				Code:      code,
+				ExpiresAt: time.Now().Add(domain.DeviceLinkCodeTTL),
+			}, nil
+		}
+	}
+}
+
+// redeemLinkCode consumes a link code and returns the actor who issued it
+func (s *AuthService) redeemLinkCode(ctx context.Context, code string) (uuid.UUID, error) {
+	actorID, err := s.linkCodes.Redeem(ctx, normalizeCode(code))
+	if errors.Is(err, domain.ErrNotFound) {
+		return uuid.Nil, domain.ErrInvalidLinkCode
+	}
+	return actorID, err
+}
+
+// RegenerateRecoveryCode replaces the actor's recovery code and returns the
+// new one, which is shown only this once
+func (s *AuthService) RegenerateRecoveryCode(ctx context.Context, actorID uuid.UUID) (string, error) {
+	code, err := newRecoveryCode()
+	if err != nil {
+		return "", err
+	}
+	if err := s.deviceRepo.SetRecoveryCode(ctx, actorID, hashCode(code), time.Now()); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// newRecoveryCode generates a recovery code like "7KQ2M-..." with 100 bits
+// of entropy, enough that storing a plain hash of it is safe
+func newRecoveryCode() (string, error) {
+	return newCode(recoveryCodeLength, recoveryGroupLength)
+}
+
+// newCode generates a random code of length characters from codeAlphabet,
+// split into dash-separated groups for readability
+func newCode(length, group int) (string, error) {
+	// 256 is a multiple of the alphabet size, so every character is
+	// equally likely
+	raw := make([]byte, length)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, c := range raw {
+		if i > 0 && i%group == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(codeAlphabet[int(c)%len(codeAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// normalizeCode makes codes typed by hand match the generated ones
+func normalizeCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(code))
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeCode(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,68 +2,117 @@ package service
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
+	"log"
 	"math"
 	"sort"
 	"time"
 
 	"github.com/forkfall/backend/internal/domain"
-	"github.com/forkfall/backend/internal/repository/postgres"
+	"github.com/forkfall/backend/internal/repository"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
 type FeedService struct {
-	forkRepo        *postgres.ForkRepository
-	interactionRepo *postgres.InteractionRepository
-	redis           *redis.Client
+	forkRepo        repository.ForkRepository
+	interactionRepo repository.InteractionRepository
+	sessions        repository.FeedSessionStore
+	cursors         cursorCodec
 }
 
 func NewFeedService(
-	forkRepo *postgres.ForkRepository,
-	interactionRepo *postgres.InteractionRepository,
-	redis *redis.Client,
+	forkRepo repository.ForkRepository,
+	interactionRepo repository.InteractionRepository,
+	sessions repository.FeedSessionStore,
+	cursorSecret string,
 ) *FeedService {
 	return &FeedService{
 		forkRepo:        forkRepo,
 		interactionRepo: interactionRepo,
-		redis:           redis,
+		sessions:        sessions,
+		cursors:         cursorCodec{key: []byte(cursorSecret)},
 	}
 }
 
-type Session struct {
-	Lane   string
-	Energy string
-}
-
-type feedCursor struct {
-	Offset int `json:"o"`
-}
-
-func (s *FeedService) GetFeed(ctx context.Context, actorID uuid.UUID, session Session, cursor string, limit int) ([]*domain.Fork, string, error) {
-	// Parse cursor
-	offset := 0
-	if cursor != "" {
-		data, err := base64.StdEncoding.DecodeString(cursor)
-		if err == nil {
-			var c feedCursor
-			json.Unmarshal(data, &c)
-			offset = c.Offset
-		}
-	}
+// Feed sessions last a day from the last update. The history of intent
+// switches, most recent first, is kept for a week after the last one.
+const (
+	sessionTTL        = 24 * time.Hour
+	sessionHistoryLen = 20
+	sessionHistoryTTL = 7 * 24 * time.Hour
+)
 
+// feedWindowFactor is how many candidates are ranked for each fork returned
+const feedWindowFactor = 3
+
+// GetFeed returns the next page of ranked forks. Each page is ranked from a
+// window of candidates: those earlier pages ranked out, topped up with forks
+// fetched in (created_at, id) order after the end of the previous window.
+// The returned cursor is signed and carries that position along with the
+// candidates this page ranked out, so inserts between pages never shift the
+// window and no candidate is skipped. A cursor minted for a different
+// intent, or one that fails verification, yields domain.ErrInvalidInput.
+func (s *FeedService) GetFeed(ctx context.Context, actorID uuid.UUID, intent domain.Intent, cursor string, limit int) ([]*domain.Fork, string, error) {
 	// Get seen fork IDs (last 24 hours)
 	seenIDs, err := s.interactionRepo.GetSeenForkIDs(ctx, actorID, time.Now().Add(-24*time.Hour))
 	if err != nil {
 		seenIDs = []uuid.UUID{}
 	}
 
-	// Fetch more forks than needed for ranking
-	fetchLimit := limit * 3
-	forks, err := s.forkRepo.GetFeed(ctx, session.Lane, session.Energy, seenIDs, fetchLimit, offset)
-	if err != nil {
-		return nil, "", err
+	// Parse cursor, picking up the candidates earlier pages left over that
+	// are still visible and unseen
+	var after *repository.FeedPosition
+	var candidates []*domain.Fork
+	asOf := time.Now()
+	if cursor != "" {
+		c, err := s.cursors.decode(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if c.Lane != intent.Lane || c.Energy != intent.Energy || c.Mood != intent.Mood {
+			return nil, "", domain.ErrInvalidInput
+		}
+		pendingIDs, err := c.pendingIDs()
+		if err != nil {
+			return nil, "", err
+		}
+		after = c.position()
+		asOf = time.Unix(c.AsOf, 0)
+
+		if len(pendingIDs) > 0 {
+			pending, err := s.forkRepo.GetVisibleByIDs(ctx, pendingIDs)
+			if err != nil {
+				return nil, "", err
+			}
+			seen := make(map[uuid.UUID]bool, len(seenIDs))
+			for _, id := range seenIDs {
+				seen[id] = true
+			}
+			for _, fork := range pending {
+				if !seen[fork.ID] {
+					candidates = append(candidates, fork)
+				}
+			}
+		}
+	}
+
+	// Top the window up with forks past the previous one
+	windowSize := limit * feedWindowFactor
+	fetchLimit := windowSize - len(candidates)
+	var fetched []*domain.Fork
+	if fetchLimit > 0 {
+		fetched, err = s.forkRepo.GetFeed(ctx, intent.Lane, intent.Energy, seenIDs, after, fetchLimit)
+		if err != nil {
+			return nil, "", err
+		}
+		candidates = append(candidates, fetched...)
+	}
+	if len(candidates) == 0 {
+		return candidates, "", nil
+	}
+	if len(fetched) > 0 {
+		last := fetched[len(fetched)-1]
+		after = &repository.FeedPosition{CreatedAt: last.CreatedAt, ID: last.ID}
 	}
 
 	// Get actor's recent interactions for diversity
@@ -72,20 +121,34 @@ func (s *FeedService) GetFeed(ctx context.Context, actorID uuid.UUID, session Se
 		interactions = []*domain.Interaction{}
 	}
 
+	// Intents the actor keeps switching to count even when not chosen now
+	switches, err := s.sessionHistory(ctx, actorID)
+	if err != nil {
+		log.Printf("Failed to load session history of actor %s: %v", actorID, err)
+	}
+
 	// Score and rank forks
-	scoredForks := s.rankForks(forks, session, interactions)
+	scoredForks := s.rankForks(candidates, intent, interactions, newIntentAffinity(switches), asOf)
 
-	// Apply limit
+	// Apply limit, keeping the rest for the next page
+	var rest []*domain.Fork
 	if len(scoredForks) > limit {
-		scoredForks = scoredForks[:limit]
+		scoredForks, rest = scoredForks[:limit], scoredForks[limit:]
 	}
 
-	// Generate next cursor
+	// Generate next cursor while candidates are left over or the window was
+	// filled from the feed, which may hold more
 	var nextCursor string
-	if len(forks) == fetchLimit {
-		c := feedCursor{Offset: offset + limit}
-		data, _ := json.Marshal(c)
-		nextCursor = base64.StdEncoding.EncodeToString(data)
+	if len(rest) > 0 || (fetchLimit > 0 && len(fetched) == fetchLimit) {
+		nextCursor = s.cursors.encode(feedCursor{
+			CreatedAt: after.CreatedAt.UnixMicro(),
+			ID:        after.ID,
+			Pending:   packIDs(rest),
+			Lane:      intent.Lane,
+			Energy:    intent.Energy,
+			Mood:      intent.Mood,
+			AsOf:      asOf.Unix(),
+		})
 	}
 
 	return scoredForks, nextCursor, nil
@@ -96,7 +159,7 @@ type scoredFork struct {
 	score float64
 }
 
-func (s *FeedService) rankForks(forks []*domain.Fork, session Session, history []*domain.Interaction) []*domain.Fork {
+func (s *FeedService) rankForks(forks []*domain.Fork, intent domain.Intent, history []*domain.Interaction, affinity intentAffinity, asOf time.Time) []*domain.Fork {
 	scored := make([]scoredFork, len(forks))
 
 	// Build seen templates set
@@ -107,12 +170,12 @@ func (s *FeedService) rankForks(forks []*domain.Fork, session Session, history [
 	}
 
 	for i, fork := range forks {
-		score := s.scoreFork(fork, session, seenLanes)
+		score := s.scoreFork(fork, intent, seenLanes, affinity, asOf)
 		scored[i] = scoredFork{fork: fork, score: score}
 	}
 
 	// Sort by score descending
-	sort.Slice(scored, func(i, j int) bool {
+	sort.SliceStable(scored, func(i, j int) bool {
 		return scored[i].score > scored[j].score
 	})
 
@@ -124,19 +187,25 @@ func (s *FeedService) rankForks(forks []*domain.Fork, session Session, history [
 	return result
 }
 
-func (s *FeedService) scoreFork(fork *domain.Fork, session Session, seenLanes map[string]int) float64 {
+func (s *FeedService) scoreFork(fork *domain.Fork, intent domain.Intent, seenLanes map[string]int, affinity intentAffinity, asOf time.Time) float64 {
 	score := 0.0
 
 	// Intent match (highest weight)
-	if fork.IntentLane == session.Lane {
+	if fork.IntentLane == intent.Lane {
 		score += 40
 	}
-	if fork.Energy == session.Energy {
+	if fork.Energy == intent.Energy {
 		score += 20
 	}
+	if intent.Mood != "" && fork.Mood == intent.Mood {
+		score += 15
+	}
+
+	// Intents recently switched to
+	score += 8 * (affinity.lanes[fork.IntentLane] + affinity.energies[fork.Energy] + affinity.moods[fork.Mood])
 
 	// Freshness (decay over 24h)
-	age := time.Since(fork.CreatedAt)
+	age := asOf.Sub(fork.CreatedAt)
 	score += 15 * math.Max(0, 1-age.Hours()/24)
 
 	// Skip penalty
@@ -165,26 +234,110 @@ func (s *FeedService) scoreFork(fork *domain.Fork, session Session, seenLanes ma
 	return score
 }
 
-func (s *FeedService) UpdateSession(ctx context.Context, actorID uuid.UUID, session Session) error {
-	// Store session in Redis
-	key := "session:" + actorID.String()
-	data, _ := json.Marshal(session)
-	return s.redis.Set(ctx, key, data, 24*time.Hour).Err()
+// intentAffinity weighs each lane, energy and mood by how often and how
+// recently the actor switched to it, from 0 (never) to 1 (every time)
+type intentAffinity struct {
+	lanes    map[string]float64
+	energies map[string]float64
+	moods    map[string]float64
 }
 
-func (s *FeedService) GetSession(ctx context.Context, actorID uuid.UUID) (*Session, error) {
-	key := "session:" + actorID.String()
-	data, err := s.redis.Get(ctx, key).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			return &Session{}, nil
+func newIntentAffinity(switches []domain.IntentSwitch) intentAffinity {
+	affinity := intentAffinity{
+		lanes:    make(map[string]float64),
+		energies: make(map[string]float64),
+		moods:    make(map[string]float64),
+	}
+
+	// The n-th most recent switch weighs 1/n
+	var total float64
+	for i, sw := range switches {
+		weight := 1 / float64(i+1)
+		total += weight
+		if sw.Lane != "" {
+			affinity.lanes[sw.Lane] += weight
 		}
-		return nil, err
+		if sw.Energy != "" {
+			affinity.energies[sw.Energy] += weight
+		}
+		if sw.Mood != "" {
+			affinity.moods[sw.Mood] += weight
+		}
+	}
+	for _, weights := range []map[string]float64{affinity.lanes, affinity.energies, affinity.moods} {
+		for id := range weights {
+			weights[id] /= total
+		}
+	}
+	return affinity
+}
+
+// UpdateSession validates and stores the actor's feed session, recording
+// the switch in their session history when the intent changed
+func (s *FeedService) UpdateSession(ctx context.Context, actorID uuid.UUID, intent domain.Intent) error {
+	if err := intent.Validate(); err != nil {
+		return err
 	}
 
-	var session Session
-	if err := json.Unmarshal(data, &session); err != nil {
+	current, err := s.GetSession(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.Set(ctx, actorID, intent, sessionTTL); err != nil {
+		return err
+	}
+	if *current == intent {
+		return nil
+	}
+
+	sw := domain.IntentSwitch{
+		Lane:   intent.Lane,
+		Energy: intent.Energy,
+		Mood:   intent.Mood,
+		At:     time.Now().Unix(),
+	}
+	return s.sessions.AddSwitch(ctx, actorID, sw, sessionHistoryLen, sessionHistoryTTL)
+}
+
+// ClearSession forgets the actor's feed session and session history
+func (s *FeedService) ClearSession(ctx context.Context, actorID uuid.UUID) error {
+	return s.sessions.Delete(ctx, actorID)
+}
+
+// GetSession returns the actor's stored feed session, or an empty one if
+// they have none. Values dropped from the intent catalog since they were
+// stored are cleared.
+func (s *FeedService) GetSession(ctx context.Context, actorID uuid.UUID) (*domain.Intent, error) {
+	intent, err := s.sessions.Get(ctx, actorID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return &domain.Intent{}, nil
+	}
+	if err != nil {
 		return nil, err
 	}
-	return &session, nil
+	known := intent.Known()
+	return &known, nil
+}
+
+// ResolveIntent merges the intent requested for a feed page over the
+// actor's stored session: fields the request leaves empty fall back to the
+// session. Requested values must be in the intent catalog. The stored
+// session is skipped if the session store can't be reached.
+func (s *FeedService) ResolveIntent(ctx context.Context, actorID uuid.UUID, requested domain.Intent) (domain.Intent, error) {
+	if err := requested.Validate(); err != nil {
+		return domain.Intent{}, err
+	}
+	session, err := s.GetSession(ctx, actorID)
+	if err != nil {
+		log.Printf("Failed to load feed session of actor %s: %v", actorID, err)
+		return requested, nil
+	}
+	return requested.Merge(*session), nil
+}
+
+// sessionHistory returns the actor's recent session switches, most recent
+// first
+func (s *FeedService) sessionHistory(ctx context.Context, actorID uuid.UUID) ([]domain.IntentSwitch, error) {
+	return s.sessions.Switches(ctx, actorID, sessionHistoryLen)
 }
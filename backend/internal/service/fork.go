@@ -2,41 +2,101 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/forkfall/backend/internal/domain"
-	"github.com/forkfall/backend/internal/repository/postgres"
+	"github.com/forkfall/backend/internal/mutation"
+	"github.com/forkfall/backend/internal/repository"
 	"github.com/google/uuid"
 )
 
 type ForkService struct {
-	forkRepo        *postgres.ForkRepository
-	interactionRepo *postgres.InteractionRepository
+	forkRepo        repository.ForkRepository
+	interactionRepo repository.InteractionRepository
+	actorRepo       repository.ActorRepository
+	moderationRepo  repository.ModerationRepository
+	masks           *MaskService
+	trust           TrustNotifier
+	mutations       *mutation.Engine
 }
 
 func NewForkService(
-	forkRepo *postgres.ForkRepository,
-	interactionRepo *postgres.InteractionRepository,
+	forkRepo repository.ForkRepository,
+	interactionRepo repository.InteractionRepository,
+	actorRepo repository.ActorRepository,
+	moderationRepo repository.ModerationRepository,
+	masks *MaskService,
+	trust TrustNotifier,
 ) *ForkService {
 	return &ForkService{
 		forkRepo:        forkRepo,
 		interactionRepo: interactionRepo,
+		actorRepo:       actorRepo,
+		moderationRepo:  moderationRepo,
+		masks:           masks,
+		trust:           trust,
+		mutations:       mutation.NewEngine(),
 	}
 }
 
 func (s *ForkService) CreateFork(ctx context.Context, actorID uuid.UUID, input domain.CreateForkInput) (*domain.Fork, error) {
+	// Twists must name their mutation and point at a twistable parent, whose
+	// unset intent fields they inherit
+	var parent *domain.Fork
+	if input.ParentForkID != nil {
+		if input.MutationType == "" {
+			return nil, &domain.FieldError{Field: "mutation_type", Err: domain.ErrMissingRequired}
+		}
+		var err error
+		parent, err = s.twistParent(ctx, *input.ParentForkID)
+		if err != nil {
+			return nil, err
+		}
+		s.mutations.Inherit(parent, &input)
+	} else if input.MutationType != "" {
+		return nil, &domain.FieldError{Field: "parent_fork_id", Err: domain.ErrMutationRequiresParent}
+	}
+
 	// Validate input
 	if err := input.Validate(); err != nil {
 		return nil, err
 	}
 
-	// Check rate limit (10 creates per hour)
-	count, err := s.interactionRepo.CountByActorSince(ctx, actorID, domain.InteractionTwist, time.Now().Add(-1*time.Hour))
+	// Check the twist really applies its mutation to the parent
+	var diff *domain.MutationDiff
+	if parent != nil {
+		var err error
+		diff, err = s.mutations.Validate(parent, &input)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check the actor may create content at all
+	actor, err := s.actorRepo.GetByID(ctx, actorID)
 	if err != nil {
 		return nil, err
 	}
-	if count >= 10 {
-		return nil, domain.ErrRateLimited
+	if err := actor.CheckCanCreate(); err != nil {
+		return nil, err
+	}
+
+	// Check creation quota
+	quota, err := s.creationQuota(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if quota.Remaining == 0 {
+		return nil, quota.exceeded()
+	}
+
+	// Attribute the fork to the actor's mask in its lane
+	mask, err := s.masks.Current(ctx, actorID, input.IntentLane)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create fork
@@ -50,22 +110,99 @@ func (s *ForkService) CreateFork(ctx context.Context, actorID uuid.UUID, input d
 		Energy:            input.Energy,
 		ParentForkID:      input.ParentForkID,
 		MutationType:      input.MutationType,
-		SafetyAgeGate:     "all",
+		MutationDiff:      diff,
+		SafetyAgeGate:     domain.AgeGateAll,
 		SafetySensitivity: "normal",
 		SafetyFlags:       []string{},
+		Status:            domain.ForkStatusVisible,
 		CreatedByActorID:  actorID,
+		CreatedByMaskID:   &mask.ID,
+		MaskHandle:        mask.Handle,
 		CreatedAt:         time.Now(),
 	}
 
-	if err := s.forkRepo.Create(ctx, fork); err != nil {
+	// The quota is enforced again as the fork is inserted, in case
+	// concurrent creations used it up since it was checked
+	err = s.forkRepo.Create(ctx, fork, time.Now().Add(-forkCreateWindow), quota.Limit)
+	if errors.Is(err, domain.ErrRateLimited) {
+		if quota, err = s.creationQuota(ctx, actor); err != nil {
+			return nil, err
+		}
+		return nil, quota.exceeded()
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrParentUnavailable) {
+			return nil, &domain.FieldError{Field: "parent_fork_id", Err: err}
+		}
 		return nil, err
 	}
 
 	return fork, nil
 }
 
+// twistParent loads a fork to be twisted, reporting missing, hidden and
+// age-gated forks alike as unavailable
+func (s *ForkService) twistParent(ctx context.Context, id uuid.UUID) (*domain.Fork, error) {
+	parent, err := s.forkRepo.GetByID(ctx, id)
+	if err == nil {
+		err = parent.CheckTwistable()
+	}
+	if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrParentUnavailable) {
+		return nil, &domain.FieldError{Field: "parent_fork_id", Err: domain.ErrParentUnavailable}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parent, nil
+}
+
+// TwistFork creates a twist of the parent generated mechanically by the
+// mutation engine. Only mutation types that can be derived from the parent
+// alone (currently flip) are supported.
+func (s *ForkService) TwistFork(ctx context.Context, actorID uuid.UUID, parentID uuid.UUID, mutationType string) (*domain.Fork, error) {
+	parent, err := s.forkRepo.GetByID(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := s.mutations.Generate(mutationType, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CreateFork(ctx, actorID, input)
+}
+
+// GetCreationQuota reports how many more forks the actor may create in the
+// current window
+func (s *ForkService) GetCreationQuota(ctx context.Context, actorID uuid.UUID) (*CreationQuota, error) {
+	actor, err := s.actorRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.creationQuota(ctx, actor)
+}
+
+func (s *ForkService) creationQuota(ctx context.Context, actor *domain.Actor) (*CreationQuota, error) {
+	createdTimes, err := s.forkRepo.GetCreatedTimesByActor(ctx, actor.ID, time.Now().Add(-forkCreateWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	return computeQuota(actor.TrustScore, createdTimes), nil
+}
+
+// GetFork returns a fork that is visible to clients. Hidden and removed
+// forks are reported as not found.
 func (s *ForkService) GetFork(ctx context.Context, id uuid.UUID) (*domain.Fork, error) {
-	return s.forkRepo.GetByID(ctx, id)
+	fork, err := s.forkRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if fork.Status != domain.ForkStatusVisible {
+		return nil, domain.ErrNotFound
+	}
+	return fork, nil
 }
 
 func (s *ForkService) RecordInteraction(ctx context.Context, actorID uuid.UUID, input domain.InteractionInput) error {
@@ -83,10 +220,52 @@ func (s *ForkService) RecordInteraction(ctx context.Context, actorID uuid.UUID,
 		CreatedAt: time.Now(),
 	}
 
-	return s.interactionRepo.Create(ctx, interaction)
+	if err := s.interactionRepo.Create(ctx, interaction); err != nil {
+		return err
+	}
+	// Swipe velocity counts toward trust
+	if domain.IsVoteType(input.Type) {
+		s.trust.Notify(actorID)
+	}
+	return nil
 }
 
+// CastVote sets the actor's single vote on a fork, replacing any earlier one.
+// Hidden and removed forks are reported as not found, as by GetFork.
+func (s *ForkService) CastVote(ctx context.Context, actorID uuid.UUID, forkID uuid.UUID, voteType string, dwellMs int) (*domain.Vote, error) {
+	if !domain.IsVoteType(voteType) {
+		return nil, domain.ErrInvalidInput
+	}
+
+	interaction := &domain.Interaction{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		ForkID:    forkID,
+		Type:      voteType,
+		DwellMs:   dwellMs,
+		CreatedAt: time.Now(),
+	}
+	if err := s.interactionRepo.Create(ctx, interaction); err != nil {
+		return nil, err
+	}
+	s.trust.Notify(actorID)
+
+	return s.interactionRepo.GetVote(ctx, actorID, forkID)
+}
+
+// RetractVote withdraws the actor's vote on a visible fork
+func (s *ForkService) RetractVote(ctx context.Context, actorID uuid.UUID, forkID uuid.UUID) error {
+	return s.interactionRepo.RetractVote(ctx, actorID, forkID, time.Now())
+}
+
+// ReportFork files a report and hides the fork pending review once its open
+// reports carry enough trust-weighted weight
 func (s *ForkService) ReportFork(ctx context.Context, actorID uuid.UUID, forkID uuid.UUID, reason string) error {
+	fork, err := s.GetFork(ctx, forkID)
+	if err != nil {
+		return err
+	}
+
 	report := &domain.Report{
 		ID:        uuid.New(),
 		ActorID:   actorID,
@@ -96,9 +275,98 @@ func (s *ForkService) ReportFork(ctx context.Context, actorID uuid.UUID, forkID
 		CreatedAt: time.Now(),
 	}
 
-	return s.forkRepo.CreateReport(ctx, report)
+	if err := s.forkRepo.CreateReport(ctx, report); err != nil {
+		return err
+	}
+	s.trust.Notify(fork.CreatedByActorID)
+
+	// The report is filed either way; a failed check is retried by the next
+	// report on the same fork
+	if err := s.autoHide(ctx, forkID); err != nil {
+		log.Printf("Auto-hide check for fork %s failed: %v", forkID, err)
+	}
+	return nil
+}
+
+func (s *ForkService) autoHide(ctx context.Context, forkID uuid.UUID) error {
+	weight, err := s.moderationRepo.OpenReportWeight(ctx, forkID)
+	if err != nil {
+		return err
+	}
+	if weight < domain.AutoHideReportWeight {
+		return nil
+	}
+
+	_, err = s.moderationRepo.TransitionFork(ctx, forkID, domain.ForkStatusHidden, "", &domain.ModerationAction{
+		ID:        uuid.New(),
+		Action:    domain.ModerationAutoHide,
+		ForkID:    forkID,
+		Note:      fmt.Sprintf("open report weight %.2f", weight),
+		CreatedAt: time.Now(),
+	}, nil)
+	if errors.Is(err, domain.ErrInvalidTransition) {
+		// Already hidden or removed
+		return nil
+	}
+	return err
 }
 
-func (s *ForkService) GetForkChildren(ctx context.Context, parentID uuid.UUID) ([]*domain.Fork, error) {
-	return s.forkRepo.GetByParent(ctx, parentID)
+// Lineage query bounds
+const (
+	LineageDefaultDepth = 3
+	LineageMaxDepth     = 10
+	lineageMaxNodes     = 500
+	lineageMaxAncestors = 100
+)
+
+// GetLineage returns the ancestor chain of a fork and the tree of twists
+// descending from it, depth levels deep
+func (s *ForkService) GetLineage(ctx context.Context, id uuid.UUID, depth int) (*domain.Lineage, error) {
+	if depth < 1 || depth > LineageMaxDepth {
+		return nil, domain.ErrInvalidInput
+	}
+
+	chain, err := s.forkRepo.GetAncestors(ctx, id, lineageMaxAncestors)
+	if err != nil {
+		return nil, err
+	}
+	fork := chain[len(chain)-1]
+	if fork.Status != domain.ForkStatusVisible {
+		return nil, domain.ErrNotFound
+	}
+
+	// Show only the part of the chain below the nearest ancestor that isn't
+	// visible
+	ancestors := chain[:len(chain)-1]
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if ancestors[i].Status != domain.ForkStatusVisible {
+			ancestors = ancestors[i+1:]
+			break
+		}
+	}
+
+	descendants, err := s.forkRepo.GetDescendants(ctx, id, depth, lineageMaxNodes+1)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := len(descendants) > lineageMaxNodes
+	if truncated {
+		descendants = descendants[:lineageMaxNodes]
+	}
+
+	// BuildForkTree drops forks whose parent is missing, pruning whole
+	// subtrees below anything that isn't visible
+	visible := descendants[:0]
+	for _, f := range descendants {
+		if f.Status == domain.ForkStatusVisible {
+			visible = append(visible, f)
+		}
+	}
+
+	return &domain.Lineage{
+		Ancestors: ancestors,
+		Tree:      domain.BuildForkTree(fork, visible),
+		Truncated: truncated,
+	}, nil
 }
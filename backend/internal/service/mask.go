@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// MaskService issues the pseudonymous personas actors create content under.
+// Each actor has one mask per lane at a time, replaced by a fresh one with
+// an unrelated handle every domain.MaskRotationInterval.
+type MaskService struct {
+	maskRepo repository.MaskRepository
+}
+
+func NewMaskService(maskRepo repository.MaskRepository) *MaskService {
+	return &MaskService{
+		maskRepo: maskRepo,
+	}
+}
+
+// Current returns the actor's mask in the lane, rotating in a new one if
+// the previous mask has expired
+func (s *MaskService) Current(ctx context.Context, actorID uuid.UUID, lane string) (*domain.Mask, error) {
+	handle, err := newMaskHandle()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rotatesAt := now.Add(domain.MaskRotationInterval)
+	return s.maskRepo.GetOrCreateCurrent(ctx, &domain.Mask{
+		ID:        uuid.New(),
+		ActorID:   actorID,
+		Lane:      lane,
+		Handle:    handle,
+		RotatesAt: &rotatesAt,
+		CreatedAt: now,
+	}, now)
+}
+
+var (
+	maskAdjectives = []string{
+		"amber", "brisk", "calm", "dusky", "eager", "fuzzy", "gentle", "hazy",
+		"idle", "jolly", "keen", "lucky", "misty", "nimble", "odd", "plucky",
+		"quiet", "rusty", "sly", "tidy", "umber", "vivid", "wily", "zesty",
+	}
+	maskNouns = []string{
+		"badger", "crane", "dingo", "egret", "ferret", "gecko", "heron", "ibis",
+		"jackal", "koala", "lemur", "marten", "newt", "otter", "panda", "quail",
+		"raven", "stoat", "tapir", "urchin", "vole", "walrus", "yak", "zebra",
+	}
+)
+
+// newMaskHandle generates a handle like "amber-otter-3f9a1c". Handles are
+// random so consecutive masks of the same actor can't be linked.
+func newMaskHandle() (string, error) {
+	adjective, err := randomWord(maskAdjectives)
+	if err != nil {
+		return "", err
+	}
+	noun, err := randomWord(maskNouns)
+	if err != nil {
+		return "", err
+	}
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return adjective + "-" + noun + "-" + hex.EncodeToString(suffix), nil
+}
+
+func randomWord(words []string) (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+	if err != nil {
+		return "", err
+	}
+	return words[i.Int64()], nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Moderation queue bounds
+const (
+	ModerationQueueDefaultLimit = 50
+	ModerationQueueMaxLimit     = 100
+	moderationLogLimit          = 100
+)
+
+// ModerationService runs the report queue and moderator decisions on forks
+type ModerationService struct {
+	moderationRepo repository.ModerationRepository
+	forkRepo       repository.ForkRepository
+	trust          TrustNotifier
+}
+
+func NewModerationService(
+	moderationRepo repository.ModerationRepository,
+	forkRepo repository.ForkRepository,
+	trust TrustNotifier,
+) *ModerationService {
+	return &ModerationService{
+		moderationRepo: moderationRepo,
+		forkRepo:       forkRepo,
+		trust:          trust,
+	}
+}
+
+// ListReports returns a page of reports in the given state, oldest first,
+// and the cursor for the next page ("" on the last page)
+func (s *ModerationService) ListReports(ctx context.Context, state, cursor string, limit int) ([]*domain.Report, string, error) {
+	if !domain.ValidReportState(state) {
+		return nil, "", &domain.FieldError{Field: "state", Err: domain.ErrInvalidInput}
+	}
+
+	var after *repository.ReportPosition
+	if cursor != "" {
+		createdAt, id, err := decodeKeyset(cursor)
+		if err != nil {
+			return nil, "", &domain.FieldError{Field: "cursor", Err: err}
+		}
+		after = &repository.ReportPosition{CreatedAt: createdAt, ID: id}
+	}
+
+	reports, err := s.moderationRepo.ListReports(ctx, state, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(reports) == limit {
+		last := reports[len(reports)-1]
+		next = encodeKeyset(last.CreatedAt, last.ID)
+	}
+	return reports, next, nil
+}
+
+// ResolveReport moves a report to state on behalf of a moderator, identified
+// by their staff ID. The admin audit entry, if any, is recorded with the
+// change.
+func (s *ModerationService) ResolveReport(ctx context.Context, moderator string, reportID uuid.UUID, state, note string, audit *domain.AdminAuditEntry) (*domain.Report, error) {
+	if !domain.ValidReportState(state) {
+		return nil, &domain.FieldError{Field: "state", Err: domain.ErrInvalidInput}
+	}
+
+	action := &domain.ModerationAction{
+		ID:        uuid.New(),
+		Moderator: moderator,
+		Action:    domain.ReportTransitionAction(state),
+		ReportID:  &reportID,
+		Note:      note,
+		CreatedAt: time.Now(),
+	}
+	if err := s.moderationRepo.TransitionReport(ctx, reportID, state, action, audit); err != nil {
+		return nil, err
+	}
+
+	report, err := s.moderationRepo.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.IsOpenReportState(state) {
+		s.notifyTrust(ctx, report.ForkID, []*domain.Report{report})
+	}
+	return report, nil
+}
+
+// RestoreFork makes a hidden or removed fork visible again and dismisses its
+// open reports, so they don't immediately hide it again. The admin audit
+// entry, if any, is recorded with the change.
+func (s *ModerationService) RestoreFork(ctx context.Context, moderator string, forkID uuid.UUID, note string, audit *domain.AdminAuditEntry) error {
+	return s.transitionFork(ctx, moderator, forkID, domain.ForkStatusVisible, domain.ReportStateDismissed, domain.ModerationRestoreFork, note, audit)
+}
+
+// RemoveFork takes a fork down and marks its open reports actioned. The
+// admin audit entry, if any, is recorded with the change.
+func (s *ModerationService) RemoveFork(ctx context.Context, moderator string, forkID uuid.UUID, note string, audit *domain.AdminAuditEntry) error {
+	return s.transitionFork(ctx, moderator, forkID, domain.ForkStatusRemoved, domain.ReportStateActioned, domain.ModerationRemoveFork, note, audit)
+}
+
+func (s *ModerationService) transitionFork(ctx context.Context, moderator string, forkID uuid.UUID, status, reportState, actionType, note string, audit *domain.AdminAuditEntry) error {
+	action := &domain.ModerationAction{
+		ID:        uuid.New(),
+		Moderator: moderator,
+		Action:    actionType,
+		ForkID:    forkID,
+		Note:      note,
+		CreatedAt: time.Now(),
+	}
+	settled, err := s.moderationRepo.TransitionFork(ctx, forkID, status, reportState, action, audit)
+	if err != nil {
+		return err
+	}
+	s.notifyTrust(ctx, forkID, settled)
+	return nil
+}
+
+// notifyTrust asks for the fork's author and the reporters to be rescored
+// after their reports were decided. A failed lookup of the author is only
+// logged; the scheduled rescoring catches up with them.
+func (s *ModerationService) notifyTrust(ctx context.Context, forkID uuid.UUID, decided []*domain.Report) {
+	actorIDs := make([]uuid.UUID, 0, len(decided)+1)
+	for _, report := range decided {
+		actorIDs = append(actorIDs, report.ActorID)
+	}
+	if fork, err := s.forkRepo.GetByID(ctx, forkID); err == nil {
+		actorIDs = append(actorIDs, fork.CreatedByActorID)
+	} else {
+		log.Printf("Failed to load fork %s for trust rescoring: %v", forkID, err)
+	}
+	s.trust.Notify(actorIDs...)
+}
+
+// GetReport looks a report up by ID
+func (s *ModerationService) GetReport(ctx context.Context, id uuid.UUID) (*domain.Report, error) {
+	return s.moderationRepo.GetReport(ctx, id)
+}
+
+// GetReporterStats counts how the actor's decided reports were resolved
+func (s *ModerationService) GetReporterStats(ctx context.Context, actorID uuid.UUID) (domain.ReporterStats, error) {
+	return s.moderationRepo.GetReporterStats(ctx, actorID)
+}
+
+// ForkReview is what a moderator sees about a fork: the fork in any status,
+// the weight of its open reports and its moderation history
+type ForkReview struct {
+	Fork         *domain.Fork
+	ReportWeight float64
+	Actions      []*domain.ModerationAction
+}
+
+// GetForkReview loads a fork for moderation, whatever its status
+func (s *ModerationService) GetForkReview(ctx context.Context, forkID uuid.UUID) (*ForkReview, error) {
+	fork, err := s.forkRepo.GetByID(ctx, forkID)
+	if err != nil {
+		return nil, err
+	}
+
+	weight, err := s.moderationRepo.OpenReportWeight(ctx, forkID)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := s.moderationRepo.ListActions(ctx, forkID, moderationLogLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForkReview{Fork: fork, ReportWeight: weight, Actions: actions}, nil
+}
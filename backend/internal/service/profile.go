@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Limits for the pages of an actor's own forks and votes
+const (
+	ProfileListDefaultLimit = 20
+	ProfileListMaxLimit     = 50
+)
+
+// Profile is what an actor sees about themselves
+type Profile struct {
+	Actor *domain.Actor
+	Stats *domain.ActorStats
+}
+
+// ProfileService serves an actor's view of their own account and activity
+type ProfileService struct {
+	actorRepo       repository.ActorRepository
+	forkRepo        repository.ForkRepository
+	interactionRepo repository.InteractionRepository
+}
+
+func NewProfileService(
+	actorRepo repository.ActorRepository,
+	forkRepo repository.ForkRepository,
+	interactionRepo repository.InteractionRepository,
+) *ProfileService {
+	return &ProfileService{
+		actorRepo:       actorRepo,
+		forkRepo:        forkRepo,
+		interactionRepo: interactionRepo,
+	}
+}
+
+// GetProfile returns the actor with their activity stats
+func (s *ProfileService) GetProfile(ctx context.Context, actorID uuid.UUID) (*Profile, error) {
+	actor, err := s.actorRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.interactionRepo.GetActorStats(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{Actor: actor, Stats: stats}, nil
+}
+
+// ListForks returns a page of the forks the actor created, newest first and
+// in any status, so they can see what moderation hid
+func (s *ProfileService) ListForks(ctx context.Context, actorID uuid.UUID, cursor string, limit int) ([]*domain.Fork, string, error) {
+	var after *repository.FeedPosition
+	if cursor != "" {
+		createdAt, id, err := decodeKeyset(cursor)
+		if err != nil {
+			return nil, "", &domain.FieldError{Field: "cursor", Err: err}
+		}
+		after = &repository.FeedPosition{CreatedAt: createdAt, ID: id}
+	}
+
+	forks, err := s.forkRepo.GetByCreator(ctx, actorID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(forks) == limit {
+		last := forks[len(forks)-1]
+		next = encodeKeyset(last.CreatedAt, last.ID)
+	}
+	return forks, next, nil
+}
+
+// ListVotes returns a page of the actor's votes on visible forks, most
+// recently cast first. A changed vote moves to the top.
+func (s *ProfileService) ListVotes(ctx context.Context, actorID uuid.UUID, cursor string, limit int) ([]*domain.VotedFork, string, error) {
+	var after *repository.VotePosition
+	if cursor != "" {
+		updatedAt, forkID, err := decodeKeyset(cursor)
+		if err != nil {
+			return nil, "", &domain.FieldError{Field: "cursor", Err: err}
+		}
+		after = &repository.VotePosition{UpdatedAt: updatedAt, ForkID: forkID}
+	}
+
+	votes, err := s.interactionRepo.GetVoteHistory(ctx, actorID, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(votes) == limit {
+		last := votes[len(votes)-1].Vote
+		next = encodeKeyset(last.UpdatedAt, last.ForkID)
+	}
+	return votes, next, nil
+}
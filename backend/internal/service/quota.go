@@ -0,0 +1,65 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+)
+
+// Fork creation quota: a sliding window of forks actually authored by the
+// actor, scaled by trust score so reliable creators get more headroom.
+const (
+	forkCreateWindow       = time.Hour
+	forkCreatesPerWindow   = 10
+	forkCreateMinQuota     = 1
+	forkCreateMaxTrustMult = 2.0
+)
+
+// CreationQuota describes an actor's fork creation allowance
+type CreationQuota struct {
+	Limit     int
+	Remaining int
+	// ResetsAt is when the next slot frees up; zero if nothing is in use
+	ResetsAt time.Time
+}
+
+// creationLimit scales the base quota by trust score, clamped to
+// [forkCreateMinQuota, forkCreatesPerWindow*forkCreateMaxTrustMult].
+func creationLimit(trustScore float64) int {
+	mult := math.Min(math.Max(trustScore, 0), forkCreateMaxTrustMult)
+	limit := int(math.Floor(forkCreatesPerWindow * mult))
+	if limit < forkCreateMinQuota {
+		return forkCreateMinQuota
+	}
+	return limit
+}
+
+// computeQuota derives the quota from the creation times within the window,
+// which must be sorted oldest first.
+func computeQuota(trustScore float64, createdTimes []time.Time) *CreationQuota {
+	quota := &CreationQuota{Limit: creationLimit(trustScore)}
+
+	used := len(createdTimes)
+	quota.Remaining = quota.Limit - used
+	if quota.Remaining < 0 {
+		quota.Remaining = 0
+	}
+
+	// A slot frees when enough of the oldest creations age out of the window
+	// to bring usage below the limit.
+	if used > 0 {
+		idx := 0
+		if used >= quota.Limit {
+			idx = used - quota.Limit
+		}
+		quota.ResetsAt = createdTimes[idx].Add(forkCreateWindow)
+	}
+
+	return quota
+}
+
+// exceeded is the error for a creation refused by the quota
+func (q *CreationQuota) exceeded() error {
+	return &domain.RateLimitError{RetryAfter: time.Until(q.ResetsAt)}
+}
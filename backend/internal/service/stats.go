@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/forkfall/backend/internal/repository"
+)
+
+// StatsService maintains the materialized per-fork interaction counters
+type StatsService struct {
+	interactionRepo repository.InteractionRepository
+}
+
+func NewStatsService(interactionRepo repository.InteractionRepository) *StatsService {
+	return &StatsService{
+		interactionRepo: interactionRepo,
+	}
+}
+
+// Reconcile rebuilds the counters from raw interactions and returns the
+// number of forks whose counters had drifted.
+func (s *StatsService) Reconcile(ctx context.Context) (int, error) {
+	return s.interactionRepo.RebuildForkStats(ctx)
+}
+
+// RunReconciler reconciles the counters every interval until ctx is done
+func (s *StatsService) RunReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			corrected, err := s.Reconcile(ctx)
+			if err != nil {
+				log.Printf("Fork stats reconciliation failed: %v", err)
+				continue
+			}
+			if corrected > 0 {
+				log.Printf("Fork stats reconciliation corrected %d forks", corrected)
+			}
+		}
+	}
+}
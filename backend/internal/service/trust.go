@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/forkfall/backend/internal/domain"
+	"github.com/forkfall/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Scheduled trust rescoring: scores older than trustStaleAfter are
+// recomputed in batches of trustBatchSize
+const (
+	trustStaleAfter = 6 * time.Hour
+	trustBatchSize  = 200
+)
+
+// TrustNotifier is told about events that may change actors' trust scores
+type TrustNotifier interface {
+	Notify(actorIDs ...uuid.UUID)
+}
+
+// TrustService computes actors' trust scores from their behaviour. Scores
+// are recomputed on a schedule and, via Notify, soon after events that
+// affect them.
+type TrustService struct {
+	trustRepo  repository.TrustRepository
+	actorCache ActorCache
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]struct{}
+	wake    chan struct{}
+}
+
+func NewTrustService(trustRepo repository.TrustRepository, actorCache ActorCache) *TrustService {
+	return &TrustService{
+		trustRepo:  trustRepo,
+		actorCache: actorCache,
+		pending:    make(map[uuid.UUID]struct{}),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Recompute scores the actor from their current signals and applies the
+// result unless staff have overridden it
+func (s *TrustService) Recompute(ctx context.Context, actorID uuid.UUID) (*domain.TrustScore, error) {
+	now := time.Now()
+	signals, err := s.trustRepo.GetSignals(ctx, actorID, now.Add(-domain.TrustReportWindow), now.Add(-domain.TrustVelocityWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.trustRepo.Get(ctx, actorID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, err
+	}
+
+	score, err := s.trustRepo.Save(ctx, domain.ComputeTrust(actorID, *signals, now))
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil || previous.Effective() != score.Effective() {
+		s.invalidateActor(ctx, actorID)
+	}
+	return score, nil
+}
+
+// Get returns the actor's latest score, or domain.ErrNotFound if they have
+// not been scored yet
+func (s *TrustService) Get(ctx context.Context, actorID uuid.UUID) (*domain.TrustScore, error) {
+	return s.trustRepo.Get(ctx, actorID)
+}
+
+// SetOverride pins the actor's trust score, or returns them to their
+// computed score when override is nil. The admin audit entry, if any, is
+// recorded with the change.
+func (s *TrustService) SetOverride(ctx context.Context, actorID uuid.UUID, override *float64, audit *domain.AdminAuditEntry) (*domain.TrustScore, error) {
+	score, err := s.trustRepo.SetOverride(ctx, actorID, override, audit)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateActor(ctx, actorID)
+
+	if override == nil {
+		// Bring the computed score up to date so it can take over
+		return s.Recompute(ctx, actorID)
+	}
+	return score, nil
+}
+
+func (s *TrustService) invalidateActor(ctx context.Context, actorID uuid.UUID) {
+	// The cached record expires on its own shortly, so this isn't fatal
+	if err := s.actorCache.InvalidateActor(ctx, actorID); err != nil {
+		log.Printf("Failed to invalidate cached actor %s: %v", actorID, err)
+	}
+}
+
+// Notify queues actors to be rescored by Run. It never blocks, and actors
+// notified repeatedly before Run gets to them are rescored once.
+func (s *TrustService) Notify(actorIDs ...uuid.UUID) {
+	s.mu.Lock()
+	for _, id := range actorIDs {
+		if id != uuid.Nil {
+			s.pending[id] = struct{}{}
+		}
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run rescores notified actors as notifications arrive and actors with stale
+// scores every interval, until ctx is done
+func (s *TrustService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+			s.recomputePending(ctx)
+		case <-ticker.C:
+			rescored, err := s.recomputeStale(ctx)
+			if err != nil {
+				log.Printf("Trust rescoring failed: %v", err)
+			}
+			if rescored > 0 {
+				log.Printf("Trust rescoring updated %d actors", rescored)
+			}
+		}
+	}
+}
+
+func (s *TrustService) recomputePending(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[uuid.UUID]struct{})
+	s.mu.Unlock()
+
+	for id := range pending {
+		if _, err := s.Recompute(ctx, id); err != nil && !errors.Is(err, domain.ErrNotFound) {
+			log.Printf("Trust rescoring for actor %s failed: %v", id, err)
+		}
+	}
+}
+
+// recomputeStale rescores every actor whose score is older than
+// trustStaleAfter and returns how many were rescored
+func (s *TrustService) recomputeStale(ctx context.Context) (int, error) {
+	before := time.Now().Add(-trustStaleAfter)
+	rescored := 0
+	for ctx.Err() == nil {
+		ids, err := s.trustRepo.ListStale(ctx, before, trustBatchSize)
+		if err != nil {
+			return rescored, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			// Give up on the pass rather than list a failing actor forever;
+			// an actor deleted since listing simply drops out
+			_, err := s.Recompute(ctx, id)
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return rescored, err
+			}
+			rescored++
+		}
+	}
+	return rescored, nil
+}
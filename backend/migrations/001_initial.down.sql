@@ -0,0 +1,7 @@
+-- FORKFALL Initial Schema (rollback)
+
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS interactions;
+DROP TABLE IF EXISTS forks;
+DROP TABLE IF EXISTS masks;
+DROP TABLE IF EXISTS actors;
@@ -1,5 +1,5 @@
 -- FORKFALL Initial Schema
--- Run with: psql -U forkfall -d forkfall -f 001_initial.sql
+-- Applied by: api migrate up
 
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
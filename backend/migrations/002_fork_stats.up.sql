@@ -0,0 +1,23 @@
+-- Per-fork interaction counters maintained incrementally on every
+-- interaction insert, replacing the full-table GROUP BY on reads.
+
+CREATE TABLE fork_stats (
+    fork_id UUID PRIMARY KEY REFERENCES forks(id) ON DELETE CASCADE,
+    left_count BIGINT NOT NULL DEFAULT 0,
+    right_count BIGINT NOT NULL DEFAULT 0,
+    skip_count BIGINT NOT NULL DEFAULT 0,
+    twist_count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Backfill from existing interactions
+INSERT INTO fork_stats (fork_id, left_count, right_count, skip_count, twist_count)
+SELECT
+    fork_id,
+    COUNT(*) FILTER (WHERE interaction_type = 'swipe_left'),
+    COUNT(*) FILTER (WHERE interaction_type = 'swipe_right'),
+    COUNT(*) FILTER (WHERE interaction_type = 'skip'),
+    COUNT(*) FILTER (WHERE interaction_type = 'twist')
+FROM interactions
+WHERE fork_id IS NOT NULL
+GROUP BY fork_id;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_forks_lane_keyset;
+DROP INDEX IF EXISTS idx_forks_feed_keyset;
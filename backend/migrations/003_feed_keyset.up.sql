@@ -0,0 +1,4 @@
+-- Keyset pagination orders the feed by (created_at, id)
+
+CREATE INDEX IF NOT EXISTS idx_forks_feed_keyset ON forks(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_forks_lane_keyset ON forks(intent_lane, energy, created_at DESC, id DESC);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS votes;
+
+DELETE FROM interactions WHERE interaction_type = 'retract';
+ALTER TABLE interactions DROP CONSTRAINT IF EXISTS interactions_interaction_type_check;
+ALTER TABLE interactions ADD CONSTRAINT interactions_interaction_type_check
+    CHECK (interaction_type IN ('swipe_left', 'swipe_right', 'skip', 'twist'));
+
+UPDATE fork_stats SET
+    left_count = (SELECT COUNT(*) FROM interactions i WHERE i.fork_id = fork_stats.fork_id AND i.interaction_type = 'swipe_left'),
+    right_count = (SELECT COUNT(*) FROM interactions i WHERE i.fork_id = fork_stats.fork_id AND i.interaction_type = 'swipe_right'),
+    skip_count = (SELECT COUNT(*) FROM interactions i WHERE i.fork_id = fork_stats.fork_id AND i.interaction_type = 'skip'),
+    updated_at = NOW();
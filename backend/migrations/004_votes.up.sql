@@ -0,0 +1,33 @@
+-- One current vote per (actor, fork). Interactions remain the append-only
+-- history; fork_stats left/right/skip counters now track votes.
+
+ALTER TABLE interactions DROP CONSTRAINT IF EXISTS interactions_interaction_type_check;
+ALTER TABLE interactions ADD CONSTRAINT interactions_interaction_type_check
+    CHECK (interaction_type IN ('swipe_left', 'swipe_right', 'skip', 'twist', 'retract'));
+
+CREATE TABLE votes (
+    actor_id UUID NOT NULL REFERENCES actors(id) ON DELETE CASCADE,
+    fork_id UUID NOT NULL REFERENCES forks(id) ON DELETE CASCADE,
+    vote_type TEXT NOT NULL CHECK (vote_type IN ('swipe_left', 'swipe_right', 'skip')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (actor_id, fork_id)
+);
+
+CREATE INDEX idx_votes_fork ON votes(fork_id, vote_type);
+
+-- Each actor's latest swipe/skip becomes their vote
+INSERT INTO votes (actor_id, fork_id, vote_type, created_at, updated_at)
+SELECT DISTINCT ON (actor_id, fork_id)
+    actor_id, fork_id, interaction_type, created_at, created_at
+FROM interactions
+WHERE interaction_type IN ('swipe_left', 'swipe_right', 'skip')
+  AND actor_id IS NOT NULL
+  AND fork_id IS NOT NULL
+ORDER BY actor_id, fork_id, created_at DESC;
+
+UPDATE fork_stats SET
+    left_count = (SELECT COUNT(*) FROM votes v WHERE v.fork_id = fork_stats.fork_id AND v.vote_type = 'swipe_left'),
+    right_count = (SELECT COUNT(*) FROM votes v WHERE v.fork_id = fork_stats.fork_id AND v.vote_type = 'swipe_right'),
+    skip_count = (SELECT COUNT(*) FROM votes v WHERE v.fork_id = fork_stats.fork_id AND v.vote_type = 'skip'),
+    updated_at = NOW();
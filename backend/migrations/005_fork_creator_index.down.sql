@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_forks_created_by_time;
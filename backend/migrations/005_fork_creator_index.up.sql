@@ -0,0 +1,3 @@
+-- Creation quota counts an actor's forks within a time window
+
+CREATE INDEX IF NOT EXISTS idx_forks_created_by_time ON forks(created_by_actor_id, created_at);
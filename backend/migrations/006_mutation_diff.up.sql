@@ -0,0 +1,3 @@
+-- Structured diff between a twist and its parent, recorded at creation
+
+ALTER TABLE forks ADD COLUMN IF NOT EXISTS mutation_diff JSONB;
@@ -0,0 +1 @@
+ALTER TABLE forks DROP COLUMN IF EXISTS status;
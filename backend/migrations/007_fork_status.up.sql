@@ -0,0 +1,4 @@
+-- Moderation status of a fork; only visible forks are served to clients
+
+ALTER TABLE forks ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'visible'
+    CHECK (status IN ('visible', 'hidden', 'removed'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_reports_state_queue;
+DROP TABLE IF EXISTS moderation_actions;
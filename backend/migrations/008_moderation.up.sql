@@ -0,0 +1,14 @@
+-- Audit log of moderation decisions, automatic and manual
+
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    moderator_id UUID,
+    action TEXT NOT NULL,
+    fork_id UUID NOT NULL REFERENCES forks(id),
+    report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_fork ON moderation_actions(fork_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_reports_state_queue ON reports(state, created_at, id);
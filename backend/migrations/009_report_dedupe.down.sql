@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_reports_actor_state;
+DROP INDEX IF EXISTS idx_reports_open_actor_fork;
@@ -0,0 +1,13 @@
+-- One open report per (actor, fork); reporter outcome lookups
+
+DELETE FROM reports r
+USING reports earlier
+WHERE r.actor_id = earlier.actor_id
+  AND r.fork_id = earlier.fork_id
+  AND r.state IN ('pending', 'reviewed')
+  AND earlier.state IN ('pending', 'reviewed')
+  AND (earlier.created_at, earlier.id) < (r.created_at, r.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open_actor_fork ON reports(actor_id, fork_id)
+    WHERE state IN ('pending', 'reviewed');
+CREATE INDEX IF NOT EXISTS idx_reports_actor_state ON reports(actor_id, state);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS admin_audit_log;
+DROP FUNCTION IF EXISTS admin_audit_log_append_only();
+
+ALTER TABLE moderation_actions ALTER COLUMN moderator DROP NOT NULL;
+ALTER TABLE moderation_actions ALTER COLUMN moderator DROP DEFAULT;
+ALTER TABLE moderation_actions RENAME COLUMN moderator TO moderator_id;
+ALTER TABLE moderation_actions ALTER COLUMN moderator_id TYPE UUID USING NULL;
@@ -0,0 +1,32 @@
+-- Admin API: staff identities replace actor IDs in the moderation log, and
+-- every admin change is written to an append-only audit log
+
+ALTER TABLE moderation_actions ALTER COLUMN moderator_id TYPE TEXT USING moderator_id::text;
+ALTER TABLE moderation_actions RENAME COLUMN moderator_id TO moderator;
+UPDATE moderation_actions SET moderator = '' WHERE moderator IS NULL;
+ALTER TABLE moderation_actions ALTER COLUMN moderator SET DEFAULT '';
+ALTER TABLE moderation_actions ALTER COLUMN moderator SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    staff_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id UUID NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_time ON admin_audit_log(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target ON admin_audit_log(target_id, created_at DESC);
+
+CREATE OR REPLACE FUNCTION admin_audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'admin_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS admin_audit_log_append_only ON admin_audit_log;
+CREATE TRIGGER admin_audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON admin_audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION admin_audit_log_append_only();
@@ -0,0 +1,13 @@
+-- Trust scores computed from behaviour, with the per-signal contributions
+-- behind each one. actors.trust_score stays the value everything else reads;
+-- it is the staff override when set and the computed score otherwise.
+
+CREATE TABLE IF NOT EXISTS actor_trust (
+    actor_id UUID PRIMARY KEY REFERENCES actors(id) ON DELETE CASCADE,
+    computed_score DOUBLE PRECISION,
+    contributions JSONB NOT NULL DEFAULT '[]',
+    computed_at TIMESTAMPTZ,
+    override_score DOUBLE PRECISION
+);
+
+CREATE INDEX IF NOT EXISTS idx_actor_trust_computed_at ON actor_trust(computed_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_masks_actor_lane;
+ALTER TABLE masks DROP COLUMN IF EXISTS handle;
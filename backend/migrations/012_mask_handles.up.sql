@@ -0,0 +1,8 @@
+-- Masks get a public handle, and lookups of an actor's current mask in a
+-- lane are indexed
+
+ALTER TABLE masks ADD COLUMN IF NOT EXISTS handle TEXT;
+UPDATE masks SET handle = 'mask-' || left(id::text, 8) WHERE handle IS NULL;
+ALTER TABLE masks ALTER COLUMN handle SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_masks_actor_lane ON masks(actor_id, lane, created_at DESC);
@@ -0,0 +1,6 @@
+-- Fails if actors have come to share a fingerprint since the up migration
+ALTER TABLE actors ADD CONSTRAINT actors_device_fingerprint_key UNIQUE (device_fingerprint);
+
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS device_id;
+
+DROP TABLE IF EXISTS devices;
@@ -0,0 +1,23 @@
+-- Devices are identified by a keypair they generate and prove possession of
+-- on every sign-in. The client-reported fingerprint becomes a lookup hint
+-- and no longer has to be unique across actors.
+
+CREATE TABLE IF NOT EXISTS devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL REFERENCES actors(id) ON DELETE CASCADE,
+    key_id TEXT NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    fingerprint TEXT NOT NULL,
+    attestation_format TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_actor ON devices(actor_id);
+
+ALTER TABLE auth_sessions
+    ADD COLUMN IF NOT EXISTS device_id UUID REFERENCES devices(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_device ON auth_sessions(device_id) WHERE revoked_at IS NULL;
+
+ALTER TABLE actors DROP CONSTRAINT IF EXISTS actors_device_fingerprint_key;
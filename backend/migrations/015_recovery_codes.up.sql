@@ -0,0 +1,9 @@
+-- Recovery codes let an actor link a new device when none of their devices
+-- is at hand. Codes are random and long, so a plain hash is stored and
+-- doubles as the lookup key.
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    actor_id UUID PRIMARY KEY REFERENCES actors(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS account_jobs;
+
+-- The placeholder actor stays if deleted accounts left forks with it
+DELETE FROM actors a
+WHERE a.id = '00000000-0000-0000-0000-000000000002'
+  AND NOT EXISTS (SELECT 1 FROM forks f WHERE f.created_by_actor_id = a.id);
@@ -0,0 +1,22 @@
+-- Account exports and deletions run as background jobs. Jobs don't
+-- reference actors so a deletion job outlives the actor it deletes.
+
+CREATE TABLE IF NOT EXISTS account_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('export', 'delete')),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    archive JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_jobs_actor ON account_jobs(actor_id, kind, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_account_jobs_queue ON account_jobs(created_at) WHERE status IN ('pending', 'running');
+
+-- Forks that outlive a deleted account are handed to this placeholder. Its
+-- empty fingerprint can't be presented at sign-in, so it can't be claimed.
+INSERT INTO actors (id, device_fingerprint, trust_score, status)
+VALUES ('00000000-0000-0000-0000-000000000002', '', 1.0, 'banned')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_votes_actor_time;
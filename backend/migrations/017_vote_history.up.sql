@@ -0,0 +1,3 @@
+-- Actors page through their own votes, most recently cast first
+
+CREATE INDEX IF NOT EXISTS idx_votes_actor_time ON votes(actor_id, updated_at DESC, fork_id DESC);
@@ -0,0 +1,12 @@
+// Package migrations embeds the versioned SQL schema files so they ship
+// inside the backend binary.
+//
+// Files are named NNN_description.up.sql with an optional matching
+// NNN_description.down.sql. Versions must be unique and are applied in
+// ascending order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
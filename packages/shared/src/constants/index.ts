@@ -86,7 +86,7 @@ export const DIVERSITY = {
 export const SAFETY = {
   AUTO_HIDE_REPORT_COUNT: 3,
   TRUST_SCORE_CREATE_MIN: 0.5,
-  TRUST_SCORE_DEFAULT: 1.0,
+  TRUST_SCORE_DEFAULT: 0.8, // a new account, after the account age penalty
 } as const;
 
 // ============ Timing ============
@@ -18,11 +18,13 @@ export interface Fork {
   cognitiveLoad?: CognitiveLoad;
   parentForkId?: string;
   mutationType?: MutationType;
+  mutationDiff?: MutationDiff;
   safetyAgeGate: AgeGate;
   safetySensitivity: Sensitivity;
   safetyFlags: SafetyFlag[];
-  createdByActorId: string;
-  createdByMaskId?: string;
+  // Forks are attributed to the creator's mask, never their actor ID
+  maskHandle?: string;
+  createdByYou: boolean;
   createdAt: string;
 
   // Aggregated stats
@@ -32,6 +34,21 @@ export interface Fork {
   twistCount: number;
 }
 
+export interface TextChange {
+  from: string;
+  to: string;
+}
+
+export interface MutationDiff {
+  prompt?: TextChange;
+  leftLabel?: TextChange;
+  rightLabel?: TextChange;
+  intentLane?: TextChange;
+  mood?: TextChange;
+  energy?: TextChange;
+  labelsSwapped?: boolean;
+}
+
 export interface Actor {
   id: string;
   deviceFingerprint: string;
@@ -44,6 +61,7 @@ export interface Mask {
   id: string;
   actorId: string;
   lane: IntentLane;
+  handle: string;
   rotatesAt?: string;
   createdAt: string;
 }
@@ -57,6 +75,12 @@ export interface Interaction {
   createdAt: string;
 }
 
+export interface Vote {
+  forkId: string;
+  type: VoteType;
+  updatedAt: string;
+}
+
 export interface Report {
   id: string;
   actorId: string;
@@ -102,13 +126,22 @@ export interface InteractInput {
 
 export interface FeedResponse {
   forks: Fork[];
+  session: Session;
   nextCursor?: string;
 }
 
+export interface ChallengeResponse {
+  challenge: string;
+  expiresAt: string;
+}
+
 export interface AuthResponse {
   token: string;
+  refreshToken: string;
+  expiresIn: number;
   actorId: string;
   isNew: boolean;
+  recoveryCode?: string;
 }
 
 export interface IntentsResponse {
@@ -127,7 +160,9 @@ export type Mood = 'playful' | 'serious' | 'spicy' | 'wholesome' | 'chaotic';
 
 export type CognitiveLoad = 'low' | 'medium' | 'high';
 
-export type InteractionType = 'swipe_left' | 'swipe_right' | 'skip' | 'twist';
+export type InteractionType = 'swipe_left' | 'swipe_right' | 'skip';
+
+export type VoteType = 'swipe_left' | 'swipe_right' | 'skip';
 
 export type MutationType = 'flip' | 'reframe' | 'escalate' | 'specific' | 'opposite';
 